@@ -0,0 +1,148 @@
+// Package cmdutil holds helpers shared by heapview commands.
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/fileutils"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+	"github.com/alexey-medvedchikov/go-heapview/internal/typeinfo"
+)
+
+// BinaryFlag is the flag pointing to the executable the dump was taken from.
+func BinaryFlag(usage string) cli.Flag {
+	return &cli.StringFlag{
+		Name:  "binary",
+		Usage: usage,
+	}
+}
+
+// OpenBinary opens the executable passed via BinaryFlag, the result is nil if the flag is not set.
+func OpenBinary(c *cli.Context) (*symtab.Table, error) {
+	binPath := c.String("binary")
+	if binPath == "" {
+		return nil, nil
+	}
+
+	return symtab.Open(binPath)
+}
+
+// ReadHeap reads the heap dump at fpath and assigns types to objects and names to stack and global slots using debug
+// information of the binary if it is given.
+func ReadHeap(fpath string, table *symtab.Table) (*heap.Heap, error) {
+	var h *heap.Heap
+
+	err := fileutils.WithFileOpened(fpath, func(fp *os.File) error {
+		var err error
+		if h, err = heap.Read(fp); err != nil {
+			return err
+		}
+		if err := typeinfo.Assign(h, table); err != nil {
+			return err
+		}
+		if err := typeinfo.NameLocals(h, table); err != nil {
+			return err
+		}
+		return typeinfo.NameGlobals(h, table)
+	}, os.O_RDONLY, 0640)
+
+	return h, err
+}
+
+// Step is a single pointer of a retention path, Offset is the offset of the pointer in the previous step.
+type Step struct {
+	Offset  uint64
+	Address heap.Address
+	Size    uint64
+	Type    string `json:",omitempty"`
+}
+
+// Path is the shortest chain of pointers from a root to an object.
+type Path struct {
+	RootKind heap.RootKind
+	Root     string
+	Steps    []Step
+}
+
+// RetentionPath returns the path to the i-th object of the graph, false means the object is unreachable.
+func RetentionPath(h *heap.Heap, i int) (Path, bool) {
+	root, ok := h.Paths().Root(i)
+	if !ok {
+		return Path{}, false
+	}
+
+	path := Path{RootKind: root.Kind, Root: root.String()}
+	for _, step := range h.Paths().Path(i) {
+		object, _ := h.Objects().Find(step.Addr)
+		typeDesc, _ := h.Objects().Type(object.Addr)
+		path.Steps = append(path.Steps, Step{
+			Offset:  step.Offset,
+			Address: step.Addr,
+			Size:    object.Size,
+			Type:    typeDesc.Name,
+		})
+	}
+
+	return path, true
+}
+
+// Location is a program counter resolved to a function and a source line.
+type Location struct {
+	PC       uint64
+	FuncName string
+	File     string
+	Line     int
+}
+
+// Symbolize resolves pc with tables of the binary, the result is nil if the binary isn't given or pc is unknown.
+func Symbolize(table *symtab.Table, pc uint64) *Location {
+	fn, ok := table.Func(pc)
+	if !ok {
+		return nil
+	}
+
+	return &Location{PC: pc, FuncName: fn.Name, File: fn.File, Line: fn.Line}
+}
+
+// SymbolizeReturn resolves a return address, e.g. PC of a suspended frame, to the line of the call preceding it.
+func SymbolizeReturn(table *symtab.Table, pc uint64) *Location {
+	if pc == 0 {
+		return nil
+	}
+
+	loc := Symbolize(table, pc-1)
+	if loc != nil {
+		loc.PC = pc
+	}
+
+	return loc
+}
+
+// GoSite describes the go statement at pc: source line if the binary is given, function name found via stack
+// frames or the bare PC otherwise.
+func GoSite(h *heap.Heap, table *symtab.Table, pc uint64) (string, *Location) {
+	if loc := SymbolizeReturn(table, pc); loc != nil {
+		return fmt.Sprintf("%s %s:%d", loc.FuncName, loc.File, loc.Line), loc
+	}
+
+	if name, ok := h.StackFrames().FuncOf(pc - 1); ok {
+		return name, nil
+	}
+
+	return fmt.Sprintf("%#x", pc), nil
+}
+
+// Section starts a section of a tabwriter report.
+func Section(w io.Writer, name string) {
+	_, _ = fmt.Fprintf(w, "%s\t\n", name)
+}
+
+// Value writes a named value of the current section of a tabwriter report.
+func Value(w io.Writer, name, v string) {
+	_, _ = fmt.Fprintf(w, "  %s\t%s\n", name, v)
+}
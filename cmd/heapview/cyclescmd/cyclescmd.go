@@ -0,0 +1,198 @@
+package cyclescmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+const maxRoots = 10
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "cycles",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to name local and global variables of roots"),
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of the largest cycles to report",
+				Value: 20,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return cyclesAction(h, c.Int("top"))
+		},
+		Usage: "Show the largest reference cycles (strongly connected components) and roots that keep them alive",
+	}
+}
+
+// root is named the same way as roots of retention paths: frame function with the local variable, global variable or
+// the kind of the root.
+type root struct {
+	Kind   heap.RootKind
+	Root   string
+	Addr   heap.Address
+	Offset uint64
+}
+
+type cycle struct {
+	start     int32
+	Count     int
+	Size      uint64
+	Path      []heap.Address
+	RootCount int
+	Roots     []root
+}
+
+func cyclesAction(h *heap.Heap, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+	comp, count := g.Components()
+
+	members := make([][]int32, count)
+	for i, c := range comp {
+		members[c] = append(members[c], int32(i))
+	}
+
+	var cycles []cycle
+	for _, m := range members {
+		if len(m) == 1 && !pointsTo(g, m[0], m[0]) {
+			continue
+		}
+
+		c := cycle{start: m[0], Count: len(m)}
+		for _, i := range m {
+			c.Size += g.Objects[i].Size
+		}
+		cycles = append(cycles, c)
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		if cycles[i].Count != cycles[j].Count {
+			return cycles[i].Count > cycles[j].Count
+		}
+		if cycles[i].Size != cycles[j].Size {
+			return cycles[i].Size > cycles[j].Size
+		}
+		return cycles[i].start < cycles[j].start
+	})
+
+	if top > 0 && len(cycles) > top {
+		cycles = cycles[:top]
+	}
+
+	roots := h.Roots().All()
+
+	for _, c := range cycles {
+		c.Path = cyclePath(g, comp, c.start)
+
+		alive := reachingObjects(g, comp, comp[c.start])
+		for _, rt := range roots {
+			target, ok := g.Index(rt.Target)
+			if !ok || !alive[target] {
+				continue
+			}
+
+			c.RootCount++
+			if len(c.Roots) < maxRoots {
+				c.Roots = append(c.Roots, root{Kind: rt.Kind, Root: rt.String(), Addr: rt.Addr, Offset: rt.Offset})
+			}
+		}
+
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pointsTo(g *heap.Graph, from, to int32) bool {
+	for _, target := range g.Edges(int(from)) {
+		if target == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cyclePath finds the shortest path from start back to itself that stays inside the component of start.
+func cyclePath(g *heap.Graph, comp []int32, start int32) []heap.Address {
+	parent := map[int32]int32{}
+	queue := []int32{start}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, target := range g.Edges(int(node)) {
+			if comp[target] != comp[start] {
+				continue
+			}
+
+			if target == start {
+				path := []heap.Address{g.Objects[start].Addr}
+				for n := node; n != start; n = parent[n] {
+					path = append(path, g.Objects[n].Addr)
+				}
+				path = append(path, g.Objects[start].Addr)
+
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+
+			if _, seen := parent[target]; !seen {
+				parent[target] = node
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reachingObjects marks objects that have a path to the component c, including the component itself.
+func reachingObjects(g *heap.Graph, comp []int32, c int32) []bool {
+	marked := make([]bool, g.Len())
+
+	var queue []int32
+	for i := range comp {
+		if comp[i] == c {
+			marked[i] = true
+			queue = append(queue, int32(i))
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		for _, referrer := range g.Referrers(int(node)) {
+			if !marked[referrer] {
+				marked[referrer] = true
+				queue = append(queue, referrer)
+			}
+		}
+	}
+
+	return marked
+}
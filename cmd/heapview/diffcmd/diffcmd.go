@@ -0,0 +1,216 @@
+package diffcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		ArgsUsage: "BEFORE AFTER",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dumps were taken from, used to group objects by type and name globals"),
+			&cli.StringSliceFlag{
+				Name:  "by",
+				Usage: "Dimensions to group objects by: root, global, site, shape, type; type is added by default with --binary",
+				Value: cli.NewStringSlice("root", "global", "site", "shape"),
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of groups with the largest change to report per dimension",
+				Value: 20,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 2 {
+				return errors.New("two heap dump files are required")
+			}
+
+			for _, by := range c.StringSlice("by") {
+				if _, ok := dimensions[by]; !ok {
+					return fmt.Errorf("unknown dimension: %s", by)
+				}
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			before, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			after, err := cmdutil.ReadHeap(c.Args().Get(1), table)
+			if err != nil {
+				return err
+			}
+
+			by := c.StringSlice("by")
+			if !c.IsSet("by") && table != nil {
+				by = append(by, "type")
+			}
+
+			return diffAction(before, after, by, c.Int("top"))
+		},
+		Usage: "Compare two heap dumps of the same process and report growth grouped by several dimensions",
+	}
+}
+
+// dimensions map a dimension name to a function returning the group key of the i-th object of the graph, objects
+// without a key are not counted in the dimension. Objects are attributed to the root of their shortest path, root
+// groups them by the function of the frame and global by the variable, named with --binary.
+var dimensions = map[string]func(h *heap.Heap, i int) (string, bool){
+	"root": func(h *heap.Heap, i int) (string, bool) {
+		if root, ok := h.Paths().Root(i); ok && root.Kind == heap.RootFrame {
+			return root.Name, true
+		}
+		return "", false
+	},
+	"global": func(h *heap.Heap, i int) (string, bool) {
+		if root, ok := h.Paths().Root(i); ok && (root.Kind == heap.RootData || root.Kind == heap.RootBSS) {
+			return root.String(), true
+		}
+		return "", false
+	},
+	"site": func(h *heap.Heap, i int) (string, bool) {
+		profile, ok := h.AllocProfiles().Of(h.Graph().Objects[i].Addr)
+		if !ok {
+			return "", false
+		}
+		site, ok := profile.Site()
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("%s %s:%d", site.FuncName, site.FileName, site.Line), true
+	},
+	"shape": func(h *heap.Heap, i int) (string, bool) {
+		return string(h.Graph().Objects[i].Shape()), true
+	},
+	"type": func(h *heap.Heap, i int) (string, bool) {
+		if typeDesc, ok := h.Objects().Type(h.Graph().Objects[i].Addr); ok {
+			return typeDesc.Name, true
+		}
+		return "unknown", true
+	},
+}
+
+// delta describes a change of a group, survivors are objects at the same address with the same shape in both dumps,
+// they are counted in the group of the second dump.
+type delta struct {
+	By            string
+	Key           string
+	BeforeCount   uint64
+	BeforeBytes   uint64
+	AfterCount    uint64
+	AfterBytes    uint64
+	CountDelta    int64
+	BytesDelta    int64
+	SurvivedCount uint64
+	SurvivedBytes uint64
+	NewCount      uint64
+	NewBytes      uint64
+	FreedCount    uint64
+	FreedBytes    uint64
+}
+
+func diffAction(before, after *heap.Heap, by []string, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	survived := func(object heap.Object, other *heap.Heap) bool {
+		otherObject, ok := other.Objects().Find(object.Addr)
+		return ok && otherObject.Addr == object.Addr && otherObject.Shape() == object.Shape()
+	}
+
+	for _, dim := range by {
+		keyFn := dimensions[dim]
+		groups := map[string]*delta{}
+
+		group := func(key string) *delta {
+			d, ok := groups[key]
+			if !ok {
+				d = &delta{By: dim, Key: key}
+				groups[key] = d
+			}
+			return d
+		}
+
+		for i, object := range before.Graph().Objects {
+			key, ok := keyFn(before, i)
+			if !ok {
+				continue
+			}
+
+			d := group(key)
+			d.BeforeCount++
+			d.BeforeBytes += object.Size
+			if !survived(object, after) {
+				d.FreedCount++
+				d.FreedBytes += object.Size
+			}
+		}
+
+		for i, object := range after.Graph().Objects {
+			key, ok := keyFn(after, i)
+			if !ok {
+				continue
+			}
+
+			d := group(key)
+			d.AfterCount++
+			d.AfterBytes += object.Size
+			if survived(object, before) {
+				d.SurvivedCount++
+				d.SurvivedBytes += object.Size
+			} else {
+				d.NewCount++
+				d.NewBytes += object.Size
+			}
+		}
+
+		sorted := make([]*delta, 0, len(groups))
+		for _, d := range groups {
+			d.CountDelta = int64(d.AfterCount) - int64(d.BeforeCount)
+			d.BytesDelta = int64(d.AfterBytes) - int64(d.BeforeBytes)
+			sorted = append(sorted, d)
+		}
+
+		sort.Slice(sorted, func(i, j int) bool {
+			a, b := abs(sorted[i].BytesDelta), abs(sorted[j].BytesDelta)
+			if a != b {
+				return a > b
+			}
+			return sorted[i].Key < sorted[j].Key
+		})
+
+		if top > 0 && len(sorted) > top {
+			sorted = sorted[:top]
+		}
+
+		for _, d := range sorted {
+			if err := encoder.Encode(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
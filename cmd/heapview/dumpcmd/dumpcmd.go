@@ -8,31 +8,70 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
 	"github.com/alexey-medvedchikov/go-heapview/internal/fileutils"
 	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
 )
 
 func Command() *cli.Command {
 	return &cli.Command{
 		Name: "dump",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to resolve PCs to source lines"),
+		},
 		Action: func(c *cli.Context) error {
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
 			fpath := c.Args().Get(0)
 			return fileutils.WithFileOpened(fpath, func(fp *os.File) error {
-				return dumpAction(fp)
+				return dumpAction(fp, table)
 			}, os.O_RDONLY, 0640)
 		},
 		Usage: "Output contents of the heap dump file in a newline-delimited JSON format",
 	}
 }
 
-func dumpAction(r io.Reader) error {
-	encoder := json.NewEncoder(os.Stdout)
+// record is a single record of the dump, Locations hold PC fields of the record resolved with the binary.
+type record struct {
+	Type      string
+	Record    any
+	Locations map[string]*cmdutil.Location `json:",omitempty"`
+}
 
-	type record struct {
-		Type   string
-		Record any
+// locations collects PC fields of a record resolved with the binary, return addresses are resolved to the call.
+type locations struct {
+	table  *symtab.Table
+	result map[string]*cmdutil.Location
+}
+
+func (l *locations) add(field string, loc *cmdutil.Location) *locations {
+	if loc != nil {
+		if l.result == nil {
+			l.result = map[string]*cmdutil.Location{}
+		}
+		l.result[field] = loc
 	}
 
+	return l
+}
+
+func (l *locations) pc(field string, pc uint64) *locations {
+	return l.add(field, cmdutil.Symbolize(l.table, pc))
+}
+
+func (l *locations) ret(field string, pc uint64) *locations {
+	return l.add(field, cmdutil.SymbolizeReturn(l.table, pc))
+}
+
+func dumpAction(r io.Reader, table *symtab.Table) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	resolve := func() *locations { return &locations{table: table} }
+
 	reader := heapfile.DumpReader{
 		OnObjectFn: func(v heapfile.Object) error {
 			return encoder.Encode(record{Type: "Object", Record: any(v)})
@@ -44,16 +83,19 @@ func dumpAction(r io.Reader) error {
 			return encoder.Encode(record{Type: "TypeDesc", Record: any(v)})
 		},
 		OnGoroutineFn: func(v heapfile.Goroutine) error {
-			return encoder.Encode(record{Type: "Goroutine", Record: any(v)})
+			locs := resolve().ret("GoStmtLocation", v.GoStmtLocation)
+			return encoder.Encode(record{Type: "Goroutine", Record: any(v), Locations: locs.result})
 		},
 		OnStackFrameFn: func(v heapfile.StackFrame) error {
-			return encoder.Encode(record{Type: "StackFrame", Record: any(v)})
+			locs := resolve().pc("EntryPC", v.EntryPC).ret("CurrentPC", v.CurrentPC).ret("ContinuationPC", v.ContinuationPC)
+			return encoder.Encode(record{Type: "StackFrame", Record: any(v), Locations: locs.result})
 		},
 		OnDumpParamsFn: func(v heapfile.DumpParams) error {
 			return encoder.Encode(record{Type: "DumpParams", Record: any(v)})
 		},
 		OnFinalizerFn: func(v heapfile.Finalizer) error {
-			return encoder.Encode(record{Type: "Finalizer", Record: any(v)})
+			locs := resolve().pc("EntryPC", v.EntryPC)
+			return encoder.Encode(record{Type: "Finalizer", Record: any(v), Locations: locs.result})
 		},
 		OnItabFn: func(v heapfile.Itab) error {
 			return encoder.Encode(record{Type: "Itab", Record: any(v)})
@@ -65,7 +107,8 @@ func dumpAction(r io.Reader) error {
 			return encoder.Encode(record{Type: "MemStats", Record: any(v)})
 		},
 		OnQueuedFinalizerFn: func(v heapfile.Finalizer) error {
-			return encoder.Encode(record{Type: "QueuedFinalizer", Record: any(v)})
+			locs := resolve().pc("EntryPC", v.EntryPC)
+			return encoder.Encode(record{Type: "QueuedFinalizer", Record: any(v), Locations: locs.result})
 		},
 		OnDataSegmentFn: func(v heapfile.Segment) error {
 			return encoder.Encode(record{Type: "DataSegment", Record: any(v)})
@@ -74,7 +117,8 @@ func dumpAction(r io.Reader) error {
 			return encoder.Encode(record{Type: "BSSSegment", Record: any(v)})
 		},
 		OnDeferFn: func(v heapfile.Defer) error {
-			return encoder.Encode(record{Type: "Defer", Record: any(v)})
+			locs := resolve().ret("PC", v.PC).pc("EntryPC", v.EntryPC)
+			return encoder.Encode(record{Type: "Defer", Record: any(v), Locations: locs.result})
 		},
 		OnPanicFn: func(v heapfile.Panic) error {
 			return encoder.Encode(record{Type: "Panic", Record: any(v)})
@@ -0,0 +1,159 @@
+package duplicatescmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+const previewSize = 32
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "duplicates",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to type objects"),
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of groups wasting the most memory to report",
+				Value: 20,
+			},
+			&cli.IntFlag{
+				Name:  "min-count",
+				Usage: "Report groups with at least this number of identical objects",
+				Value: 2,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return duplicatesAction(h, c.Int("min-count"), c.Int("top"))
+		},
+		Usage: "Group identical objects and estimate how much memory deduplication or interning would save",
+	}
+}
+
+// group is a set of objects with equal contents and pointer layout, pointer values included, WastedBytes is memory that would be
+// freed if all of them were replaced by a single copy.
+type group struct {
+	Count       int
+	Size        uint64
+	WastedBytes uint64
+	Shape       heap.Shape
+	Type        string `json:",omitempty"`
+	Preview     []byte `json:",omitempty"`
+	Sample      heap.Address
+	Path        *cmdutil.Path `json:",omitempty"`
+	sample      int
+	reachable   bool
+}
+
+type groupKey struct {
+	shape heap.Shape
+	hash  uint64
+}
+
+func duplicatesAction(h *heap.Heap, minCount, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+	// Groups are found by shape and a hash of contents, objects are compared with the sample, so colliding hashes
+	// keep different objects in separate groups
+	byKey := map[groupKey][]*group{}
+	var groups []*group
+
+	for i, object := range g.Objects {
+		key := groupKey{shape: object.Shape(), hash: contentsHash(object.Contents)}
+		_, reachable := h.Paths().Root(i)
+
+		var gr *group
+		for _, candidate := range byKey[key] {
+			if bytes.Equal(g.Objects[candidate.sample].Contents, object.Contents) {
+				gr = candidate
+				break
+			}
+		}
+
+		if gr == nil {
+			gr = &group{Size: object.Size, Shape: key.shape, sample: i, reachable: reachable}
+			byKey[key] = append(byKey[key], gr)
+			groups = append(groups, gr)
+		} else if reachable && !gr.reachable {
+			// Unreachable objects are garbage, a reachable one explains better why the copies exist
+			gr.sample, gr.reachable = i, true
+		}
+		gr.Count++
+	}
+
+	var sorted []*group
+	for _, gr := range groups {
+		if gr.Count < minCount {
+			continue
+		}
+
+		gr.WastedBytes = uint64(gr.Count-1) * gr.Size
+		gr.Sample = g.Objects[gr.sample].Addr
+		sorted = append(sorted, gr)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].WastedBytes != sorted[j].WastedBytes {
+			return sorted[i].WastedBytes > sorted[j].WastedBytes
+		}
+		return sorted[i].Sample < sorted[j].Sample
+	})
+
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+
+	for _, gr := range sorted {
+		object := g.Objects[gr.sample]
+
+		if typeDesc, ok := h.Objects().Type(object.Addr); ok {
+			gr.Type = typeDesc.Name
+		}
+
+		if len(object.PointerOffsets) == 0 {
+			preview := object.Contents
+			if len(preview) > previewSize {
+				preview = preview[:previewSize]
+			}
+			gr.Preview = preview
+		}
+
+		if path, ok := cmdutil.RetentionPath(h, gr.sample); ok {
+			gr.Path = &path
+		}
+
+		if err := encoder.Encode(gr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// contentsHash hashes all bytes of an object. Pointer values are hashed too: objects pointing to different copies of
+// the same data are not duplicates of each other, the copies themselves are.
+func contentsHash(contents []byte) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write(contents)
+
+	return hash.Sum64()
+}
@@ -0,0 +1,181 @@
+package finalizerscmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "finalizers",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to symbolize finalizer functions"),
+		},
+		Action: func(c *cli.Context) error {
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return finalizersAction(h, table)
+		},
+		Usage: "Group objects with finalizers by finalizer function, show what they keep alive and detect cycles",
+	}
+}
+
+type object struct {
+	Address    heap.Address
+	Size       int
+	Type       string `json:",omitempty"`
+	OwnedSize  int
+	OwnedCount int
+	Queued     bool
+	InCycle    bool
+}
+
+// group aggregates objects by finalizer function, OwnedSize is a sum over the objects, so memory shared between them
+// is counted more than once.
+type group struct {
+	EntryPC    uint64
+	FuncName   string `json:",omitempty"`
+	File       string `json:",omitempty"`
+	Line       int    `json:",omitempty"`
+	Count      int
+	Queued     int
+	Cycles     int
+	Size       int
+	OwnedSize  int
+	OwnedCount int
+	Objects    []object
+}
+
+func finalizersAction(h *heap.Heap, table *symtab.Table) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	var finalizers []heap.Finalizer
+	err := h.Finalizers().Walk(func(finalizer heap.Finalizer) error {
+		finalizers = append(finalizers, finalizer)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	g := h.Graph()
+
+	// Owned memory of all objects is computed in one batched pass, finalizers without an object get no stats
+	var indexes []int
+	for _, finalizer := range finalizers {
+		if i, ok := g.Index(finalizer.Object); ok {
+			indexes = append(indexes, i)
+		}
+	}
+	stats := g.Owned(indexes)
+	inCycle := cycleMembers(g)
+
+	groups := map[uint64]*group{}
+
+	for _, finalizer := range finalizers {
+		gr, ok := groups[finalizer.EntryPC]
+		if !ok {
+			gr = &group{EntryPC: finalizer.EntryPC}
+			if fn, ok := table.Func(finalizer.EntryPC); ok {
+				gr.FuncName, gr.File, gr.Line = fn.Name, fn.File, fn.Line
+			} else {
+				gr.FuncName, _ = h.StackFrames().FuncName(finalizer.EntryPC)
+			}
+			groups[finalizer.EntryPC] = gr
+		}
+
+		obj := object{Address: finalizer.Object, Queued: finalizer.Queued}
+		if i, ok := g.Index(finalizer.Object); ok {
+			o := g.Objects[i]
+			typeDesc, _ := h.Objects().Type(o.Addr)
+			obj.Size = int(o.Size)
+			obj.Type = typeDesc.Name
+			obj.OwnedSize = int(stats[0].OwnedSize)
+			obj.OwnedCount = int(stats[0].OwnedCount)
+			obj.InCycle = inCycle[i]
+			stats = stats[1:]
+		}
+
+		gr.Count++
+		gr.Size += obj.Size
+		gr.OwnedSize += obj.OwnedSize
+		gr.OwnedCount += obj.OwnedCount
+		if obj.Queued {
+			gr.Queued++
+		}
+		if obj.InCycle {
+			gr.Cycles++
+		}
+		gr.Objects = append(gr.Objects, obj)
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	for _, gr := range groups {
+		sort.Slice(gr.Objects, func(i, j int) bool {
+			a, b := gr.Objects[i], gr.Objects[j]
+			if a.Size+a.OwnedSize != b.Size+b.OwnedSize {
+				return a.Size+a.OwnedSize > b.Size+b.OwnedSize
+			}
+			return a.Address < b.Address
+		})
+		sorted = append(sorted, gr)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Size+sorted[i].OwnedSize != sorted[j].Size+sorted[j].OwnedSize {
+			return sorted[i].Size+sorted[i].OwnedSize > sorted[j].Size+sorted[j].OwnedSize
+		}
+		return sorted[i].EntryPC < sorted[j].EntryPC
+	})
+
+	for _, gr := range sorted {
+		if err := encoder.Encode(gr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cycleMembers marks objects reachable from themselves: members of strongly connected components with more than one
+// object and objects pointing to themselves. Such an object with a finalizer set is never collected, as the runtime
+// keeps everything reachable from a finalizer-bearing object alive.
+func cycleMembers(g *heap.Graph) []bool {
+	comp, count := g.Components()
+
+	sizes := make([]int, count)
+	for _, c := range comp {
+		sizes[c]++
+	}
+
+	inCycle := make([]bool, g.Len())
+	for i := range inCycle {
+		if sizes[comp[i]] > 1 {
+			inCycle[i] = true
+			continue
+		}
+		for _, target := range g.Edges(i) {
+			if int(target) == i {
+				inCycle[i] = true
+				break
+			}
+		}
+	}
+
+	return inCycle
+}
@@ -0,0 +1,178 @@
+package goroutinescmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+const maxSamples = 10
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "goroutines",
+		ArgsUsage: "DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to resolve go statements to source lines"),
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of creation sites with the most goroutines to report",
+				Value: 20,
+			},
+			&cli.BoolFlag{
+				Name:  "list",
+				Usage: "List every goroutine with its frames, defer and panic chains instead of grouping them",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("heap dump file is required")
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("list") {
+				return listAction(h, table)
+			}
+
+			return sitesAction(h, table, c.Int("top"))
+		},
+		Usage: "Group goroutines by the go statement that created them with their states and memory",
+	}
+}
+
+// site aggregates goroutines created by the same go statement and started with the same function. RetainedBytes
+// counts objects that would be freed if the goroutines exited, objects reachable from several sites are left out.
+type site struct {
+	Site          string
+	Location      *cmdutil.Location `json:",omitempty"`
+	StartFunc     string            `json:",omitempty"`
+	Count         int
+	Statuses      map[string]int
+	WaitReasons   map[string]int `json:",omitempty"`
+	StackBytes    uint64
+	RetainedBytes uint64
+	Goroutines    []uint64
+}
+
+func sitesAction(h *heap.Heap, table *symtab.Table, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	sites := map[string]*site{}
+	goroutineSites := map[heap.Address]string{}
+
+	err := h.Goroutines().Walk(func(goroutine heap.Goroutine) error {
+		name, loc := cmdutil.GoSite(h, table, goroutine.GoStmtLocation)
+		startFunc, _ := h.Goroutines().StartFunc(goroutine)
+
+		key := name + "\x00" + startFunc
+		s, ok := sites[key]
+		if !ok {
+			s = &site{
+				Site:        name,
+				Location:    loc,
+				StartFunc:   startFunc,
+				Statuses:    map[string]int{},
+				WaitReasons: map[string]int{},
+			}
+			sites[key] = s
+		}
+		goroutineSites[goroutine.Addr] = key
+
+		s.Count++
+		s.Statuses[goroutine.StatusName()]++
+		if goroutine.WaitReason != "" {
+			s.WaitReasons[goroutine.WaitReason]++
+		}
+		for _, frame := range h.Goroutines().Frames(goroutine) {
+			s.StackBytes += frame.Size
+		}
+		s.Goroutines = append(s.Goroutines, goroutine.ID)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Objects pointed to from frames belong to the site of the goroutine. Objects reachable from several sites or also
+	// from globals and other roots are not retained by any site, they outlive the goroutines.
+	g := h.Graph()
+	keys := make([]string, 0, len(sites))
+	for key := range sites {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	siteIndex := map[string]int{}
+	for k, key := range keys {
+		siteIndex[key] = k
+	}
+
+	groups := make([][]int, len(keys))
+	var others []int
+	for _, root := range h.Roots().All() {
+		i, ok := g.Index(root.Target)
+		if !ok {
+			continue
+		}
+
+		if root.Kind == heap.RootFrame {
+			if goroutine, ok := h.Goroutines().OfFrame(root.Addr); ok {
+				k := siteIndex[goroutineSites[goroutine.Addr]]
+				groups[k] = append(groups[k], i)
+				continue
+			}
+		}
+		others = append(others, i)
+	}
+
+	for k, stats := range g.RetainedGroups(groups, others) {
+		sites[keys[k]].RetainedBytes = stats.OwnedSize
+	}
+
+	sorted := make([]*site, 0, len(sites))
+	for _, s := range sites {
+		sort.Slice(s.Goroutines, func(i, j int) bool { return s.Goroutines[i] < s.Goroutines[j] })
+		if len(s.Goroutines) > maxSamples {
+			s.Goroutines = s.Goroutines[:maxSamples]
+		}
+		sorted = append(sorted, s)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		if sorted[i].Site != sorted[j].Site {
+			return sorted[i].Site < sorted[j].Site
+		}
+		return sorted[i].StartFunc < sorted[j].StartFunc
+	})
+
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+
+	for _, s := range sorted {
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
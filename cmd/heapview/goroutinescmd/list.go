@@ -0,0 +1,162 @@
+package goroutinescmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+// goroutine is a single goroutine with its stack, deferred calls in the order they will run and active panics from
+// the most recent one.
+type goroutine struct {
+	ID         uint64
+	Status     string
+	WaitReason string `json:",omitempty"`
+	Site       string
+	StartFunc  string `json:",omitempty"`
+	Frames     []frame
+	Defers     []deferredCall `json:",omitempty"`
+	Panics     []activePanic  `json:",omitempty"`
+}
+
+type frame struct {
+	FuncName string
+	Size     uint64
+	Location *cmdutil.Location `json:",omitempty"`
+}
+
+// deferredCall is a defer record, ArgBytes is the closure holding the arguments of the call together with everything
+// reachable from it.
+type deferredCall struct {
+	Addr     heap.Address
+	FuncName string            `json:",omitempty"`
+	Location *cmdutil.Location `json:",omitempty"`
+	Caller   *cmdutil.Location `json:",omitempty"`
+	FuncVal  heap.Address
+	ArgBytes uint64
+	ArgCount uint64
+}
+
+// activePanic is a panic record, DataBytes is the memory reachable from the panic value.
+type activePanic struct {
+	Addr      heap.Address
+	Type      string `json:",omitempty"`
+	Data      heap.Address
+	DataBytes uint64
+	Defer     heap.Address `json:",omitempty"`
+}
+
+func listAction(h *heap.Heap, table *symtab.Table) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	var goroutines []goroutine
+	err := h.Goroutines().Walk(func(gr heap.Goroutine) error {
+		site, _ := cmdutil.GoSite(h, table, gr.GoStmtLocation)
+		startFunc, _ := h.Goroutines().StartFunc(gr)
+
+		record := goroutine{
+			ID:         gr.ID,
+			Status:     gr.StatusName(),
+			WaitReason: gr.WaitReason,
+			Site:       site,
+			StartFunc:  startFunc,
+		}
+
+		for _, f := range h.Goroutines().Frames(gr) {
+			record.Frames = append(record.Frames, frame{
+				FuncName: f.FuncName,
+				Size:     f.Size,
+				Location: cmdutil.SymbolizeReturn(table, f.CurrentPC),
+			})
+		}
+
+		for _, d := range h.Goroutines().Defers(gr) {
+			call := deferredCall{
+				Addr:     d.Addr,
+				Location: cmdutil.Symbolize(table, d.EntryPC),
+				Caller:   cmdutil.SymbolizeReturn(table, d.PC),
+				FuncVal:  d.FuncVal,
+			}
+			if call.Location != nil {
+				call.FuncName = call.Location.FuncName
+			} else if name, ok := h.StackFrames().FuncName(d.EntryPC); ok {
+				call.FuncName = name
+			} else {
+				call.FuncName, _ = h.StackFrames().FuncOf(d.EntryPC)
+			}
+
+			record.Defers = append(record.Defers, call)
+		}
+
+		for _, p := range h.Goroutines().Panics(gr) {
+			record.Panics = append(record.Panics, activePanic{
+				Addr:  p.Addr,
+				Type:  typeName(h, p.Type),
+				Data:  p.Data,
+				Defer: p.Defer,
+			})
+		}
+
+		goroutines = append(goroutines, record)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(goroutines, func(i, j int) bool { return goroutines[i].ID < goroutines[j].ID })
+
+	// Memory reachable from closures and panic values of all goroutines is computed with a single walker
+	g := h.Graph()
+	var groups [][]int
+	for _, record := range goroutines {
+		for _, call := range record.Defers {
+			groups = append(groups, objectGroup(g, call.FuncVal))
+		}
+		for _, p := range record.Panics {
+			groups = append(groups, objectGroup(g, p.Data))
+		}
+	}
+
+	owned := g.OwnedGroups(groups)
+	for _, record := range goroutines {
+		for k := range record.Defers {
+			record.Defers[k].ArgBytes, record.Defers[k].ArgCount = owned[0].OwnedSize, owned[0].OwnedCount
+			owned = owned[1:]
+		}
+		for k := range record.Panics {
+			record.Panics[k].DataBytes = owned[0].OwnedSize
+			owned = owned[1:]
+		}
+	}
+
+	for _, record := range goroutines {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// objectGroup returns the object addr points into as a group of OwnedGroups, the group is empty for addresses outside
+// of the heap.
+func objectGroup(g *heap.Graph, addr heap.Address) []int {
+	if i, ok := g.Index(addr); ok {
+		return []int{i}
+	}
+
+	return nil
+}
+
+func typeName(h *heap.Heap, addr heap.Address) string {
+	if desc, ok := h.TypeDescs().Lookup(addr); ok {
+		return desc.Name
+	}
+
+	return ""
+}
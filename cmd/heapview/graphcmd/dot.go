@@ -0,0 +1,125 @@
+package graphcmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/units"
+)
+
+// maxOffsets is the number of field offsets written on an edge, an object pointing to the same object from an array
+// would make the label unreadable.
+const maxOffsets = 4
+
+var nodeStyles = map[nodeKind]string{
+	nodeObject:  `shape=box, style="rounded"`,
+	nodeRoot:    `shape=box, style="filled", fillcolor="lightblue"`,
+	nodeSummary: `shape=note, style="dashed"`,
+}
+
+func (g *graph) writeDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	_, _ = bw.WriteString("digraph heap {\n")
+	_, _ = bw.WriteString("\tnode [fontname=\"monospace\", fontsize=10];\n")
+	_, _ = bw.WriteString("\tedge [fontname=\"monospace\", fontsize=9];\n")
+
+	for id, n := range g.nodes {
+		style := nodeStyles[n.kind]
+		if n.start {
+			style = `shape=box, style="rounded,filled,bold", fillcolor="gold"`
+		}
+		_, _ = fmt.Fprintf(bw, "\tn%d [label=%s, %s];\n", id, quote(n.lines), style)
+	}
+
+	for _, e := range g.edges {
+		if e.label == "" {
+			_, _ = fmt.Fprintf(bw, "\tn%d -> n%d;\n", e.from, e.to)
+			continue
+		}
+		_, _ = fmt.Fprintf(bw, "\tn%d -> n%d [label=%s];\n", e.from, e.to, quote([]string{e.label}))
+	}
+
+	_, _ = bw.WriteString("}\n")
+
+	return bw.Flush()
+}
+
+// quote makes a DOT string of lines separated with line breaks.
+func quote(lines []string) string {
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(line)
+	}
+
+	return `"` + strings.Join(escaped, `\n`) + `"`
+}
+
+func objectLabel(h *heap.Heap, i int) []string {
+	object := h.Graph().Objects[i]
+
+	name := "shape " + string(object.Shape())
+	if typeDesc, ok := h.Objects().Type(object.Addr); ok && typeDesc.Name != "" {
+		name = typeDesc.Name
+	}
+
+	retained, _ := h.Dominators().Retained(i)
+
+	return []string{
+		fmt.Sprintf("%#x", object.Addr),
+		name,
+		fmt.Sprintf("size %s, retained %s", units.Bytes(object.Size), units.Bytes(retained)),
+	}
+}
+
+func summaryLabel(g *heap.Graph, collapsed []neighbor, roots int) []string {
+	var lines []string
+	if len(collapsed) > 0 {
+		var size uint64
+		for _, n := range collapsed {
+			size += g.Objects[n.index].Size
+		}
+		lines = append(lines, fmt.Sprintf("%d more objects", len(collapsed)), fmt.Sprintf("size %s", units.Bytes(size)))
+	}
+	if roots > 0 {
+		lines = append(lines, fmt.Sprintf("%d more roots", roots))
+	}
+
+	return lines
+}
+
+func rootLabel(h *heap.Heap, root heap.Root) []string {
+	lines := []string{string(root.Kind)}
+
+	switch root.Kind {
+	case heap.RootFrame:
+		lines = append(lines, root.String())
+		if goroutine, ok := h.Goroutines().OfFrame(root.Addr); ok {
+			lines = append(lines, fmt.Sprintf("goroutine %d", goroutine.ID))
+		}
+	case heap.RootData, heap.RootBSS:
+		lines = append(lines, root.String())
+	default:
+		if root.Name != "" {
+			lines = append(lines, root.Name)
+		}
+	}
+
+	return lines
+}
+
+func offsetsLabel(offsets []uint64) string {
+	labels := make([]string, 0, maxOffsets+1)
+	for k, offset := range offsets {
+		if k == maxOffsets {
+			labels = append(labels, fmt.Sprintf("+%d more", len(offsets)-k))
+			break
+		}
+		labels = append(labels, fmt.Sprintf("+%#x", offset))
+	}
+
+	return strings.Join(labels, ",")
+}
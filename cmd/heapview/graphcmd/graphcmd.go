@@ -0,0 +1,314 @@
+package graphcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+const (
+	directionReferrers = "referrers"
+	directionReferents = "referents"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "graph",
+		ArgsUsage: "ADDR DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to type objects and name roots"),
+			&cli.StringFlag{
+				Name:  "direction",
+				Usage: "Follow pointers to the object (referrers) or from it (referents)",
+				Value: directionReferrers,
+			},
+			&cli.IntFlag{
+				Name:  "depth",
+				Usage: "Number of pointer hops from the object to follow",
+				Value: 3,
+			},
+			&cli.IntFlag{
+				Name:  "max-nodes",
+				Usage: "Number of nodes to draw, roots and summary nodes included",
+				Value: 50,
+			},
+			&cli.IntFlag{
+				Name:  "fanout",
+				Usage: "Number of neighbours, objects and roots, to draw per object, the rest are collapsed into a summary node",
+				Value: 8,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "File to write the graph to, standard output by default",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 2 {
+				return errors.New("address and heap dump file are required")
+			}
+
+			addr, err := strconv.ParseUint(c.Args().Get(0), 0, 64)
+			if err != nil {
+				return fmt.Errorf("invalid address: %w", err)
+			}
+
+			direction := c.String("direction")
+			if direction != directionReferrers && direction != directionReferents {
+				return fmt.Errorf("unknown direction: %s", direction)
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(1), table)
+			if err != nil {
+				return err
+			}
+
+			start, ok := h.Graph().Index(heap.Address(addr))
+			if !ok {
+				return fmt.Errorf("no object at %#x", addr)
+			}
+
+			g := neighborhood(h, start, options{
+				referrers: direction == directionReferrers,
+				depth:     c.Int("depth"),
+				maxNodes:  c.Int("max-nodes"),
+				fanout:    c.Int("fanout"),
+			})
+
+			fpath := c.String("output")
+			if fpath == "" {
+				return g.writeDOT(os.Stdout)
+			}
+
+			fp, err := os.Create(fpath)
+			if err != nil {
+				return err
+			}
+
+			if err := g.writeDOT(fp); err != nil {
+				_ = fp.Close()
+				return err
+			}
+
+			return fp.Close()
+		},
+		Usage: "Draw the neighborhood of an object as a Graphviz DOT digraph",
+	}
+}
+
+type options struct {
+	referrers bool
+	depth     int
+	maxNodes  int
+	fanout    int
+}
+
+type nodeKind int
+
+const (
+	nodeObject nodeKind = iota
+	nodeRoot
+	nodeSummary
+)
+
+type node struct {
+	kind  nodeKind
+	lines []string
+	start bool
+}
+
+// edge always goes from the pointer to the pointed object, whatever the direction of the walk is.
+type edge struct {
+	from, to int
+	label    string
+}
+
+type graph struct {
+	nodes []node
+	edges []edge
+}
+
+// neighbor is an object adjacent to the current one with offsets of pointer fields linking them.
+type neighbor struct {
+	index   int
+	offsets []uint64
+}
+
+// neighborhood walks the graph breadth-first from the start object up to the depth. Roots pointing to an object come
+// first among its referrers, objects are ordered by retained size; roots of the start object are drawn in both
+// directions. Neighbours beyond the fanout or the node limit are drawn as a single summary node, every node counts
+// against the limit and places for summary nodes are kept, the walk stops once the limit is reached.
+func neighborhood(h *heap.Heap, start int, opts options) *graph {
+	hg := h.Graph()
+	d := h.Dominators()
+
+	// Roots are keyed by the object they point into, interior pointers included
+	roots := map[int][]heap.Root{}
+	for _, root := range h.Roots().All() {
+		if i, ok := hg.Index(root.Target); ok {
+			roots[i] = append(roots[i], root)
+		}
+	}
+
+	g := &graph{}
+	ids := map[int]int{}
+
+	addNode := func(n node) int {
+		g.nodes = append(g.nodes, n)
+		return len(g.nodes) - 1
+	}
+
+	type item struct {
+		index int
+		depth int
+	}
+
+	ids[start] = addNode(node{kind: nodeObject, lines: objectLabel(h, start), start: true})
+	queue := []item{{index: start}}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		if it.depth >= opts.depth {
+			continue
+		}
+		current := ids[it.index]
+
+		var itemRoots []heap.Root
+		if opts.referrers || it.index == start {
+			itemRoots = roots[it.index]
+		}
+
+		// Roots and objects go to separate summary nodes when they are drawn in opposite directions
+		reserve := 1
+		if !opts.referrers && len(itemRoots) > 0 {
+			reserve = 2
+		}
+		if len(g.nodes)+reserve > opts.maxNodes {
+			break
+		}
+		hasRoom := func() bool {
+			return len(g.nodes)+reserve < opts.maxNodes
+		}
+
+		var neighbors []neighbor
+		if opts.referrers {
+			neighbors = referrers(hg, it.index)
+		} else {
+			neighbors = referents(hg, it.index)
+		}
+		sort.SliceStable(neighbors, func(a, b int) bool {
+			sizeA, _ := d.Retained(neighbors[a].index)
+			sizeB, _ := d.Retained(neighbors[b].index)
+			return sizeA > sizeB
+		})
+
+		drawn, collapsedRoots := 0, 0
+		for _, root := range itemRoots {
+			if drawn >= opts.fanout || !hasRoom() {
+				collapsedRoots++
+				continue
+			}
+			drawn++
+			id := addNode(node{kind: nodeRoot, lines: rootLabel(h, root)})
+			g.edges = append(g.edges, edge{from: id, to: current, label: fmt.Sprintf("+%#x", root.Offset)})
+		}
+
+		var collapsed []neighbor
+		for _, n := range neighbors {
+			id, seen := ids[n.index]
+			if !seen {
+				if drawn >= opts.fanout || !hasRoom() {
+					collapsed = append(collapsed, n)
+					continue
+				}
+				id = addNode(node{kind: nodeObject, lines: objectLabel(h, n.index)})
+				ids[n.index] = id
+				queue = append(queue, item{index: n.index, depth: it.depth + 1})
+			}
+			drawn++
+			g.link(current, id, offsetsLabel(n.offsets), opts.referrers)
+		}
+
+		if opts.referrers {
+			if len(collapsed) > 0 || collapsedRoots > 0 {
+				id := addNode(node{kind: nodeSummary, lines: summaryLabel(hg, collapsed, collapsedRoots)})
+				g.link(current, id, fmt.Sprintf("x%d", len(collapsed)+collapsedRoots), true)
+			}
+			continue
+		}
+
+		if collapsedRoots > 0 {
+			id := addNode(node{kind: nodeSummary, lines: summaryLabel(hg, nil, collapsedRoots)})
+			g.edges = append(g.edges, edge{from: id, to: current, label: fmt.Sprintf("x%d", collapsedRoots)})
+		}
+		if len(collapsed) > 0 {
+			id := addNode(node{kind: nodeSummary, lines: summaryLabel(hg, collapsed, 0)})
+			g.link(current, id, fmt.Sprintf("x%d", len(collapsed)), false)
+		}
+	}
+
+	return g
+}
+
+// link adds an edge between the current node and a neighbour found by the walk.
+func (g *graph) link(current, neighbor int, label string, referrers bool) {
+	if referrers {
+		g.edges = append(g.edges, edge{from: neighbor, to: current, label: label})
+	} else {
+		g.edges = append(g.edges, edge{from: current, to: neighbor, label: label})
+	}
+}
+
+// referents returns objects the i-th object points to with offsets of the pointer fields.
+func referents(g *heap.Graph, i int) []neighbor {
+	var result []neighbor
+	pos := map[int]int{}
+
+	offsets := g.EdgeOffsets(i)
+	for k, target := range g.Edges(i) {
+		if p, ok := pos[int(target)]; ok {
+			result[p].offsets = append(result[p].offsets, offsets[k])
+			continue
+		}
+		pos[int(target)] = len(result)
+		result = append(result, neighbor{index: int(target), offsets: []uint64{offsets[k]}})
+	}
+
+	return result
+}
+
+// referrers returns objects pointing to the i-th object with offsets of the pointer fields inside them.
+func referrers(g *heap.Graph, i int) []neighbor {
+	var result []neighbor
+	seen := map[int32]struct{}{}
+
+	for _, ref := range g.Referrers(i) {
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+
+		n := neighbor{index: int(ref)}
+		offsets := g.EdgeOffsets(int(ref))
+		for k, target := range g.Edges(int(ref)) {
+			if int(target) == i {
+				n.offsets = append(n.offsets, offsets[k])
+			}
+		}
+		result = append(result, n)
+	}
+
+	return result
+}
@@ -0,0 +1,215 @@
+package grepcmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "grep",
+		ArgsUsage: "PATTERN DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to type objects"),
+			&cli.BoolFlag{
+				Name:  "hex",
+				Usage: "Pattern is a hex-encoded byte sequence",
+			},
+			&cli.BoolFlag{
+				Name:  "regexp",
+				Usage: "Pattern is a regular expression",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Stop after this number of matches, zero means no limit",
+				Value: 100,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 2 {
+				return errors.New("pattern and heap dump file are required")
+			}
+
+			match, err := matcher(c.Args().Get(0), c.Bool("hex"), c.Bool("regexp"))
+			if err != nil {
+				return err
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(1), table)
+			if err != nil {
+				return err
+			}
+
+			return grepAction(h, match, c.Bool("hex"), c.Int("limit"))
+		},
+		Usage: "Search object, stack frame and segment contents and show who holds the matches",
+	}
+}
+
+// matcher returns a function finding start and end offsets of all non-overlapping matches.
+func matcher(pattern string, isHex, isRegexp bool) (func(b []byte) [][]int, error) {
+	if isHex && isRegexp {
+		return nil, errors.New("--hex and --regexp are mutually exclusive")
+	}
+
+	if isRegexp {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return func(b []byte) [][]int {
+			// Empty matches, e.g. of "a*", would hit every offset of every object
+			matches := re.FindAllIndex(b, -1)
+			nonEmpty := matches[:0]
+			for _, m := range matches {
+				if m[1] > m[0] {
+					nonEmpty = append(nonEmpty, m)
+				}
+			}
+			return nonEmpty
+		}, nil
+	}
+
+	needle := []byte(pattern)
+	if isHex {
+		var err error
+		if needle, err = hex.DecodeString(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(needle) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+
+	return func(b []byte) [][]int {
+		var matches [][]int
+		for start := 0; ; {
+			i := bytes.Index(b[start:], needle)
+			if i < 0 {
+				return matches
+			}
+			matches = append(matches, []int{start + i, start + i + len(needle)})
+			start += i + len(needle)
+		}
+	}, nil
+}
+
+// hit is a single match, Address is the start of the object, stack frame or segment containing it. Matched bytes are
+// in Match if they are valid UTF-8 and in MatchHex hex-encoded otherwise or if the pattern was given in hex.
+type hit struct {
+	Kind     string
+	Address  heap.Address
+	Offset   int
+	Match    string `json:",omitempty"`
+	MatchHex string `json:",omitempty"`
+	Size     uint64
+	Type     string        `json:",omitempty"`
+	FuncName string        `json:",omitempty"`
+	Path     *cmdutil.Path `json:",omitempty"`
+}
+
+var errLimitReached = errors.New("limit reached")
+
+func grepAction(h *heap.Heap, match func(b []byte) [][]int, isHex bool, limit int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	count := 0
+	emit := func(hit hit, matched []byte) error {
+		if limit > 0 && count >= limit {
+			return errLimitReached
+		}
+		count++
+
+		// JSON strings can't hold arbitrary bytes, invalid UTF-8 would be replaced
+		if isHex || !utf8.Valid(matched) {
+			hit.MatchHex = hex.EncodeToString(matched)
+		} else {
+			hit.Match = string(matched)
+		}
+		return encoder.Encode(hit)
+	}
+
+	err := func() error {
+		g := h.Graph()
+		for i, object := range g.Objects {
+			for _, m := range match(object.Contents) {
+				hit := hit{
+					Kind:    "object",
+					Address: object.Addr,
+					Offset:  m[0],
+					Size:    object.Size,
+				}
+				if typeDesc, ok := h.Objects().Type(object.Addr); ok {
+					hit.Type = typeDesc.Name
+				}
+				if path, ok := cmdutil.RetentionPath(h, i); ok {
+					hit.Path = &path
+				}
+
+				if err := emit(hit, object.Contents[m[0]:m[1]]); err != nil {
+					return err
+				}
+			}
+		}
+
+		var frames []heap.StackFrame
+		_ = h.StackFrames().Walk(func(frame heap.StackFrame) error {
+			frames = append(frames, frame)
+			return nil
+		})
+		sort.Slice(frames, func(i, j int) bool { return frames[i].Addr < frames[j].Addr })
+
+		for _, frame := range frames {
+			for _, m := range match(frame.Contents) {
+				err := emit(hit{
+					Kind:     "frame",
+					Address:  frame.Addr,
+					Offset:   m[0],
+					Size:     frame.Size,
+					FuncName: frame.FuncName,
+				}, frame.Contents[m[0]:m[1]])
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return h.Segments().Walk(func(segment heap.Segment) error {
+			for _, m := range match(segment.Contents) {
+				err := emit(hit{
+					Kind:    string(segment.Kind),
+					Address: segment.Addr,
+					Offset:  m[0],
+					Size:    uint64(len(segment.Contents)),
+				}, segment.Contents[m[0]:m[1]])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}()
+
+	if errors.Is(err, errLimitReached) {
+		return nil
+	}
+
+	return err
+}
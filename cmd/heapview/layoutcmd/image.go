@@ -0,0 +1,140 @@
+package layoutcmd
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/sizeclass"
+)
+
+const (
+	// maxCells limits the size of the image, a cell covers several pages when the heap range is large.
+	maxCells = 1 << 20
+	// cellPixels is the side of a cell in pixels.
+	cellPixels = 4
+	// levels is the number of occupancy levels cells are colored by.
+	levels = 8
+)
+
+// grid is the occupancy map, every cell holds the occupancy level of its pages: zero for empty cells, one to levels
+// otherwise.
+type grid struct {
+	width     int
+	cells     []int
+	cellBytes uint64
+	start     uint64
+}
+
+// newGrid splits pages of the layout into cells, an empty layout gets a single empty cell, so the image is still valid.
+func newGrid(l layout, width int) grid {
+	cellPages := (len(l.occupied) + maxCells - 1) / maxCells
+	if cellPages < 1 {
+		cellPages = 1
+	}
+	cells := (len(l.occupied) + cellPages - 1) / cellPages
+	if cells < 1 {
+		cells = 1
+	}
+
+	g := grid{
+		width:     width,
+		cells:     make([]int, cells),
+		cellBytes: uint64(cellPages) * sizeclass.PageSize,
+		start:     l.start,
+	}
+
+	for i := range g.cells {
+		last := (i + 1) * cellPages
+		if last > len(l.occupied) {
+			last = len(l.occupied)
+		}
+
+		var occupied uint64
+		for _, n := range l.occupied[i*cellPages : last] {
+			occupied += n
+		}
+		if occupied > 0 {
+			g.cells[i] = 1 + int(occupied*(levels-1)/g.cellBytes)
+		}
+	}
+
+	return g
+}
+
+func (g grid) rows() int {
+	return (len(g.cells) + g.width - 1) / g.width
+}
+
+// palette goes from light gray for empty cells through yellow for sparse to dark red for dense ones.
+func palette(level int) color.RGBA {
+	if level == 0 {
+		return color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+	}
+
+	from, to := color.RGBA{R: 0xff, G: 0xed, B: 0xa0}, color.RGBA{R: 0xbd, G: 0x00, B: 0x26}
+	mix := func(a, b uint8) uint8 {
+		return uint8(int(a) + (int(b)-int(a))*(level-1)/(levels-1))
+	}
+
+	return color.RGBA{R: mix(from.R, to.R), G: mix(from.G, to.G), B: mix(from.B, to.B), A: 0xff}
+}
+
+var renderers = map[string]func(w io.Writer, g grid) error{
+	".png": renderPNG,
+	".svg": renderSVG,
+}
+
+func renderPNG(w io.Writer, g grid) error {
+	img := image.NewRGBA(image.Rect(0, 0, g.width*cellPixels, g.rows()*cellPixels))
+
+	for i, level := range g.cells {
+		c := palette(level)
+		x, y := i%g.width*cellPixels, i/g.width*cellPixels
+		for dy := 0; dy < cellPixels; dy++ {
+			for dx := 0; dx < cellPixels; dx++ {
+				img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// renderSVG draws runs of cells with the same level in a row as a single rectangle, every row has a title with the
+// address range it covers.
+func renderSVG(w io.Writer, g grid) error {
+	bw := bufio.NewWriter(w)
+
+	_, _ = fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		g.width*cellPixels, g.rows()*cellPixels)
+
+	for row := 0; row < g.rows(); row++ {
+		first := row * g.width
+		last := first + g.width
+		if last > len(g.cells) {
+			last = len(g.cells)
+		}
+		rowStart := g.start + uint64(first)*g.cellBytes
+
+		_, _ = fmt.Fprintf(bw, "<g><title>%#x-%#x</title>\n", rowStart, rowStart+uint64(last-first)*g.cellBytes)
+		for i := first; i < last; {
+			j := i
+			for j < last && g.cells[j] == g.cells[i] {
+				j++
+			}
+			c := palette(g.cells[i])
+			_, _ = fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="#%02x%02x%02x"/>`+"\n",
+				(i-first)*cellPixels, row*cellPixels, (j-i)*cellPixels, cellPixels, c.R, c.G, c.B)
+			i = j
+		}
+		_, _ = fmt.Fprintln(bw, "</g>")
+	}
+
+	_, _ = fmt.Fprintln(bw, "</svg>")
+
+	return bw.Flush()
+}
@@ -0,0 +1,61 @@
+package layoutcmd
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/sizeclass"
+)
+
+func TestNewGrid(t *testing.T) {
+	tests := []struct {
+		name     string
+		occupied []uint64
+		want     []int
+	}{
+		{name: "empty heap", occupied: nil, want: []int{0}},
+		{name: "one empty page", occupied: []uint64{0}, want: []int{0}},
+		{name: "one full page", occupied: []uint64{sizeclass.PageSize}, want: []int{levels}},
+		{name: "pages", occupied: []uint64{0, sizeclass.PageSize / 2, sizeclass.PageSize}, want: []int{0, 4, levels}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGrid(layout{start: 0xc000000000, occupied: tt.occupied}, 4)
+			if len(g.cells) != len(tt.want) {
+				t.Fatalf("got %d cells, want %d", len(g.cells), len(tt.want))
+			}
+			for i := range tt.want {
+				if g.cells[i] != tt.want[i] {
+					t.Errorf("cell %d: got level %d, want %d", i, g.cells[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEmptyHeap(t *testing.T) {
+	g := newGrid(layout{}, 16)
+
+	var out bytes.Buffer
+	if err := renderPNG(&out, g); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 16*cellPixels || size.Y != cellPixels {
+		t.Errorf("got image of %v, want %dx%d", size, 16*cellPixels, cellPixels)
+	}
+
+	out.Reset()
+	if err := renderSVG(&out, g); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "</svg>\n") {
+		t.Errorf("unterminated SVG: %q", out.String())
+	}
+}
@@ -0,0 +1,264 @@
+package layoutcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/sizeclass"
+	"github.com/alexey-medvedchikov/go-heapview/internal/units"
+)
+
+const (
+	// arenaSize is the size of heap arenas on 64-bit platforms except Windows.
+	arenaSize = 64 << 20
+	// denseOccupancy is the share of a page occupied by objects for the page to be dense, pages below it are sparse.
+	denseOccupancy = 0.5
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "layout",
+		ArgsUsage: "DUMP",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "image",
+				Usage: "File to render the occupancy map to, the format is chosen by the extension: .svg or .png",
+			},
+			&cli.IntFlag{
+				Name:  "width",
+				Usage: "Number of cells per row of the image",
+				Value: 512,
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of the largest gaps to report",
+				Value: 10,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("heap dump file is required")
+			}
+
+			image := c.String("image")
+			if image != "" && renderers[filepath.Ext(image)] == nil {
+				return fmt.Errorf("unknown image format: %s", image)
+			}
+			if c.Int("width") <= 0 {
+				return errors.New("width must be positive")
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), nil)
+			if err != nil {
+				return err
+			}
+
+			return layoutAction(h, image, c.Int("width"), c.Int("top"))
+		},
+		Usage: "Show occupancy of the heap address range: arenas, dense and sparse regions and the largest gaps",
+	}
+}
+
+// layout is the heap address range split into pages, occupied holds the number of bytes of objects in every page.
+type layout struct {
+	start    uint64
+	end      uint64
+	occupied []uint64
+}
+
+func newLayout(h *heap.Heap) layout {
+	objects := h.Graph().Objects
+
+	l := layout{start: h.Params().HeapStartAddr, end: h.Params().HeapEndAddr}
+	if len(objects) > 0 {
+		last := objects[len(objects)-1]
+		if uint64(objects[0].Addr) < l.start {
+			l.start = uint64(objects[0].Addr)
+		}
+		if uint64(last.Addr)+last.Size > l.end {
+			l.end = uint64(last.Addr) + last.Size
+		}
+	}
+	l.start -= l.start % sizeclass.PageSize
+
+	l.occupied = make([]uint64, (l.end-l.start+sizeclass.PageSize-1)/sizeclass.PageSize)
+	for _, object := range objects {
+		for addr, end := uint64(object.Addr), uint64(object.Addr)+object.Size; addr < end; {
+			page := (addr - l.start) / sizeclass.PageSize
+			pageEnd := l.start + (page+1)*sizeclass.PageSize
+			if pageEnd > end {
+				pageEnd = end
+			}
+			n := pageEnd - addr
+			l.occupied[page] += n
+			addr += n
+		}
+	}
+
+	return l
+}
+
+// pageKind classifies a page as empty, sparse or dense.
+type pageKind int
+
+const (
+	emptyPage pageKind = iota
+	sparsePage
+	densePage
+)
+
+var pageKindNames = [...]string{"empty", "sparse", "dense"}
+
+func kindOf(occupied uint64) pageKind {
+	switch {
+	case occupied == 0:
+		return emptyPage
+	case float64(occupied) < denseOccupancy*sizeclass.PageSize:
+		return sparsePage
+	default:
+		return densePage
+	}
+}
+
+// gap is a range without objects.
+type gap struct {
+	start uint64
+	size  uint64
+}
+
+func layoutAction(h *heap.Heap, image string, width, top int) error {
+	l := newLayout(h)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	var objectBytes, usedPages uint64
+	for _, occupied := range l.occupied {
+		objectBytes += occupied
+		if occupied > 0 {
+			usedPages++
+		}
+	}
+	rangeSize := l.end - l.start
+
+	cmdutil.Section(w, "Heap")
+	cmdutil.Value(w, "Range", fmt.Sprintf("%#x-%#x", l.start, l.end))
+	cmdutil.Value(w, "Size", units.Bytes(rangeSize))
+	cmdutil.Value(w, "ObjectBytes", fmt.Sprintf("%s\t%s of range", units.Bytes(objectBytes), units.Percent(objectBytes, rangeSize)))
+	cmdutil.Value(w, "UsedPages", fmt.Sprintf("%s\t%s of range", units.Bytes(usedPages*sizeclass.PageSize),
+		units.Percent(usedPages*sizeclass.PageSize, rangeSize)))
+	if m, ok := h.MemStats(); ok {
+		cmdutil.Value(w, "HeapSys", units.Bytes(m.HeapSys))
+		cmdutil.Value(w, "HeapInuse", fmt.Sprintf("%s\t%s of HeapSys", units.Bytes(m.HeapInuse), units.Percent(m.HeapInuse, m.HeapSys)))
+		cmdutil.Value(w, "HeapAlloc", fmt.Sprintf("%s\t%s of HeapInuse", units.Bytes(m.HeapAlloc), units.Percent(m.HeapAlloc, m.HeapInuse)))
+	}
+
+	cmdutil.Section(w, "Arenas")
+	pagesPerArena := uint64(arenaSize / sizeclass.PageSize)
+	for first := uint64(0); first < uint64(len(l.occupied)); {
+		arenaStart := (l.start + first*sizeclass.PageSize) &^ (arenaSize - 1)
+		last := (arenaStart + arenaSize - l.start) / sizeclass.PageSize
+		if last > uint64(len(l.occupied)) {
+			last = uint64(len(l.occupied))
+		}
+
+		var bytes, used uint64
+		for _, occupied := range l.occupied[first:last] {
+			bytes += occupied
+			if occupied > 0 {
+				used++
+			}
+		}
+		if bytes > 0 {
+			cmdutil.Value(w, fmt.Sprintf("%#x", arenaStart), fmt.Sprintf("%s\t%d/%d pages used\t%s occupied",
+				units.Bytes(bytes), used, pagesPerArena, units.Percent(bytes, arenaSize)))
+		}
+
+		first = last
+	}
+
+	cmdutil.Section(w, "Regions")
+	var pages, largest [len(pageKindNames)]uint64
+	var runs [len(pageKindNames)]uint64
+	for i := 0; i < len(l.occupied); {
+		kind := kindOf(l.occupied[i])
+		j := i
+		for j < len(l.occupied) && kindOf(l.occupied[j]) == kind {
+			j++
+		}
+		n := uint64(j - i)
+		pages[kind] += n
+		runs[kind]++
+		if n > largest[kind] {
+			largest[kind] = n
+		}
+		i = j
+	}
+	for kind, name := range pageKindNames {
+		cmdutil.Value(w, name, fmt.Sprintf("%s\t%d regions\tlargest %s", units.Bytes(pages[kind]*sizeclass.PageSize),
+			runs[kind], units.Bytes(largest[kind]*sizeclass.PageSize)))
+	}
+
+	cmdutil.Section(w, "Gaps")
+	for _, g := range largestGaps(h, l, top) {
+		cmdutil.Value(w, fmt.Sprintf("%#x", g.start), units.Bytes(g.size))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if image == "" {
+		return nil
+	}
+
+	fp, err := os.Create(image)
+	if err != nil {
+		return err
+	}
+
+	if err := renderers[filepath.Ext(image)](fp, newGrid(l, width)); err != nil {
+		_ = fp.Close()
+		return err
+	}
+
+	return fp.Close()
+}
+
+// largestGaps returns the top largest ranges between objects, including ranges at both ends of the heap.
+func largestGaps(h *heap.Heap, l layout, top int) []gap {
+	var gaps []gap
+
+	prevEnd := l.start
+	for _, object := range h.Graph().Objects {
+		if uint64(object.Addr) > prevEnd {
+			gaps = append(gaps, gap{start: prevEnd, size: uint64(object.Addr) - prevEnd})
+		}
+		if end := uint64(object.Addr) + object.Size; end > prevEnd {
+			prevEnd = end
+		}
+	}
+	if l.end > prevEnd {
+		gaps = append(gaps, gap{start: prevEnd, size: l.end - prevEnd})
+	}
+
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].size != gaps[j].size {
+			return gaps[i].size > gaps[j].size
+		}
+		return gaps[i].start < gaps[j].start
+	})
+
+	if top > 0 && len(gaps) > top {
+		gaps = gaps[:top]
+	}
+
+	return gaps
+}
@@ -0,0 +1,190 @@
+package leakscmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "leaks",
+		ArgsUsage: "DUMP1 DUMP2 DUMP3 [DUMP...]",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dumps were taken from, used to type objects"),
+			&cli.StringFlag{
+				Name: "match",
+				Usage: "How objects are matched between dumps: address (same address and shape) or content " +
+					"(address match with equal non-pointer contents)",
+				Value: "address",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of the largest groups to report",
+				Value: 20,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() < 3 {
+				return errors.New("at least three heap dump files are required")
+			}
+
+			match := c.String("match")
+			if match != "address" && match != "content" {
+				return fmt.Errorf("unknown match mode: %s", match)
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			var heaps []*heap.Heap
+			for _, fpath := range c.Args().Slice() {
+				h, err := cmdutil.ReadHeap(fpath, table)
+				if err != nil {
+					return err
+				}
+				heaps = append(heaps, h)
+			}
+
+			return leaksAction(heaps, match == "content", c.Int("top"))
+		},
+		Usage: "Find objects allocated between consecutive dumps that survive in all later dumps",
+	}
+}
+
+// group collects leaked objects by root and shape in the last dump, PerInterval counts objects allocated between
+// each pair of consecutive dumps.
+type group struct {
+	Root        string
+	RootKind    heap.RootKind `json:",omitempty"`
+	Shape       heap.Shape
+	Type        string `json:",omitempty"`
+	Count       uint64
+	Bytes       uint64
+	Intervals   int
+	PerInterval []uint64
+	Sample      heap.Address
+	Site        string        `json:",omitempty"`
+	Path        *cmdutil.Path `json:",omitempty"`
+	sample      int
+}
+
+// leaksAction runs three-snapshot technique on every window of the series: objects that are absent in the first
+// dump of the window, present in the second one and in all later dumps are leak candidates.
+func leaksAction(heaps []*heap.Heap, matchContent bool, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	identical := func(object heap.Object, from, to *heap.Heap) bool {
+		other, ok := to.Objects().Find(object.Addr)
+		if !ok || other.Addr != object.Addr || other.Shape() != object.Shape() {
+			return false
+		}
+
+		fromProfile, fromSampled := from.AllocProfiles().Of(object.Addr)
+		toProfile, toSampled := to.AllocProfiles().Of(object.Addr)
+		if fromSampled && toSampled && fromProfile.ID != toProfile.ID {
+			return false
+		}
+
+		return !matchContent || other.ContentHash() == object.ContentHash()
+	}
+
+	last := heaps[len(heaps)-1]
+	intervals := len(heaps) - 2
+	groups := map[string]*group{}
+
+	for i := 0; i < intervals; i++ {
+		prev, next := heaps[i], heaps[i+1]
+
+		for _, object := range next.Graph().Objects {
+			if identical(object, next, prev) {
+				continue
+			}
+
+			survived := true
+			for _, later := range heaps[i+2:] {
+				if survived = identical(object, next, later); !survived {
+					break
+				}
+			}
+			if !survived {
+				continue
+			}
+
+			idx, _ := last.Graph().Index(object.Addr)
+
+			root := "unreachable"
+			if r, ok := last.Paths().Root(idx); ok {
+				root = r.String()
+			}
+
+			key := root + "\x00" + string(object.Shape())
+			g, ok := groups[key]
+			if !ok {
+				g = &group{
+					Root:        root,
+					Shape:       object.Shape(),
+					PerInterval: make([]uint64, intervals),
+					Sample:      object.Addr,
+					sample:      idx,
+				}
+				groups[key] = g
+			}
+
+			if g.PerInterval[i] == 0 {
+				g.Intervals++
+			}
+			g.PerInterval[i]++
+			g.Count++
+			g.Bytes += object.Size
+		}
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Bytes != sorted[j].Bytes {
+			return sorted[i].Bytes > sorted[j].Bytes
+		}
+		return sorted[i].Sample < sorted[j].Sample
+	})
+
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+
+	for _, g := range sorted {
+		if typeDesc, ok := last.Objects().Type(g.Sample); ok {
+			g.Type = typeDesc.Name
+		}
+
+		if profile, ok := last.AllocProfiles().Of(g.Sample); ok {
+			if site, ok := profile.Site(); ok {
+				g.Site = fmt.Sprintf("%s %s:%d", site.FuncName, site.FileName, site.Line)
+			}
+		}
+
+		if path, ok := cmdutil.RetentionPath(last, g.sample); ok {
+			g.RootKind = path.RootKind
+			g.Path = &path
+		}
+
+		if err := encoder.Encode(g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
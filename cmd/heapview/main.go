@@ -6,8 +6,26 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cyclescmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/diffcmd"
 	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/dumpcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/duplicatescmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/finalizerscmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/goroutinescmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/graphcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/grepcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/layoutcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/leakscmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/memstatscmd"
 	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/ownedcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/pprofcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/scancostcmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/shapescmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/sizeclassescmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/stringscmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/suspectscmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/threadscmd"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/whatiscmd"
 	"github.com/alexey-medvedchikov/go-heapview/internal/profile"
 )
 
@@ -25,8 +43,26 @@ func newApp() *cli.App {
 	return &cli.App{
 		Name: "heapview",
 		Commands: cli.Commands{
+			cyclescmd.Command(),
+			diffcmd.Command(),
 			dumpcmd.Command(),
+			duplicatescmd.Command(),
+			finalizerscmd.Command(),
+			goroutinescmd.Command(),
+			graphcmd.Command(),
+			grepcmd.Command(),
+			layoutcmd.Command(),
+			leakscmd.Command(),
+			memstatscmd.Command(),
 			ownedcmd.Command(),
+			pprofcmd.Command(),
+			scancostcmd.Command(),
+			shapescmd.Command(),
+			sizeclassescmd.Command(),
+			stringscmd.Command(),
+			suspectscmd.Command(),
+			threadscmd.Command(),
+			whatiscmd.Command(),
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
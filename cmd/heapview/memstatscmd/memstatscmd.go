@@ -0,0 +1,160 @@
+package memstatscmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/fileutils"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+	"github.com/alexey-medvedchikov/go-heapview/internal/units"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "memstats",
+		Action: func(c *cli.Context) error {
+			fpath := c.Args().Get(0)
+			return fileutils.WithFileOpened(fpath, func(fp *os.File) error {
+				return memStatsAction(fp)
+			}, os.O_RDONLY, 0640)
+		},
+		Usage: "Show runtime memory statistics and GC pause distribution recorded in the heap dump",
+	}
+}
+
+func memStatsAction(r io.Reader) error {
+	h, err := heap.Read(r)
+	if err != nil {
+		return err
+	}
+
+	m, ok := h.MemStats()
+	if !ok {
+		return errors.New("MemStats record is missing in the dump")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	cmdutil.Section(w, "Heap")
+	size(w, "HeapAlloc", m.HeapAlloc, m.HeapSys)
+	size(w, "HeapInuse", m.HeapInuse, m.HeapSys)
+	size(w, "HeapIdle", m.HeapIdle, m.HeapSys)
+	size(w, "HeapReleased", m.HeapReleased, m.HeapSys)
+	size(w, "HeapSys", m.HeapSys, m.Sys)
+	count(w, "HeapObjects", m.HeapObjects)
+	count(w, "Mallocs", m.Mallocs)
+	count(w, "Frees", m.Frees)
+	size(w, "TotalAlloc", m.TotalAlloc, 0)
+
+	cmdutil.Section(w, "Stack")
+	size(w, "StackInuse", m.StackInuse, m.StackSys)
+	size(w, "StackSys", m.StackSys, m.Sys)
+
+	cmdutil.Section(w, "Spans")
+	size(w, "MSpanInuse", m.MSpanInuse, m.MSpanSys)
+	size(w, "MSpanSys", m.MSpanSys, m.Sys)
+	size(w, "MCacheInuse", m.MCacheInuse, m.MCacheSys)
+	size(w, "MCacheSys", m.MCacheSys, m.Sys)
+
+	cmdutil.Section(w, "Other")
+	size(w, "BuckHashSys", m.BuckHashSys, m.Sys)
+	size(w, "GCSys", m.GCSys, m.Sys)
+	size(w, "OtherSys", m.OtherSys, m.Sys)
+	size(w, "Sys", m.Sys, 0)
+
+	cmdutil.Section(w, "GC")
+	count(w, "NumGC", m.NumGC)
+	size(w, "NextGC", m.NextGC, 0)
+	if m.LastGC != 0 {
+		cmdutil.Value(w, "LastGC", time.Unix(0, int64(m.LastGC)).UTC().Format(time.RFC3339Nano))
+	}
+	cmdutil.Value(w, "PauseTotal", time.Duration(m.PauseTotalNs).String())
+
+	pauses := recentPauses(m)
+	if len(pauses) > 0 {
+		cmdutil.Value(w, fmt.Sprintf("Pauses (last %d)", len(pauses)), "")
+		for _, p := range []float64{50, 90, 99, 100} {
+			cmdutil.Value(w, fmt.Sprintf("  p%g", p), percentile(pauses, p).String())
+		}
+	}
+
+	var objectCount, objectBytes uint64
+	_ = h.Objects().Walk(func(object heap.Object) error {
+		objectCount++
+		objectBytes += object.Size
+		return nil
+	})
+
+	cmdutil.Section(w, "Dump")
+	cmdutil.Value(w, "Objects", fmt.Sprintf("%d\t%s of HeapObjects", objectCount, units.Percent(objectCount, m.HeapObjects)))
+	cmdutil.Value(w, "ObjectBytes", fmt.Sprintf("%s\t%s of HeapAlloc", units.Bytes(objectBytes),
+		units.Percent(objectBytes, m.HeapAlloc)))
+	cmdutil.Value(w, "Unaccounted", fmt.Sprintf("%d objects\t%s", int64(m.HeapObjects)-int64(objectCount),
+		signedBytes(int64(m.HeapAlloc)-int64(objectBytes))))
+
+	return w.Flush()
+}
+
+// recentPauses returns GC pauses from the PauseNs circular buffer, the most recent pause is stored at
+// (NumGC+255)%256 and only the last 256 pauses are kept.
+func recentPauses(m heapfile.MemStats) []time.Duration {
+	n := m.NumGC
+	if n > uint64(len(m.PauseNs)) {
+		n = uint64(len(m.PauseNs))
+	}
+
+	pauses := make([]time.Duration, 0, n)
+	for i := uint64(0); i < n; i++ {
+		idx := (m.NumGC + uint64(len(m.PauseNs)) - 1 - i) % uint64(len(m.PauseNs))
+		pauses = append(pauses, time.Duration(m.PauseNs[idx]))
+	}
+
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i] < pauses[j] })
+
+	return pauses
+}
+
+// percentile uses nearest-rank method on sorted values, the smallest value with at least p percent of values not
+// greater than it.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
+
+func count(w io.Writer, name string, n uint64) {
+	cmdutil.Value(w, name, fmt.Sprintf("%d", n))
+}
+
+func size(w io.Writer, name string, n, total uint64) {
+	if total == 0 {
+		cmdutil.Value(w, name, units.Bytes(n))
+		return
+	}
+
+	cmdutil.Value(w, name, fmt.Sprintf("%s\t%s", units.Bytes(n), units.Percent(n, total)))
+}
+
+func signedBytes(n int64) string {
+	if n < 0 {
+		return "-" + units.Bytes(uint64(-n))
+	}
+
+	return units.Bytes(uint64(n))
+}
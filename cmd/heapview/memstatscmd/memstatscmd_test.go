@@ -0,0 +1,35 @@
+package memstatscmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	six := []time.Duration{1, 2, 3, 4, 5, 6}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{name: "single value", sorted: []time.Duration{7}, p: 99, want: 7},
+		{name: "two values median", sorted: []time.Duration{1, 2}, p: 50, want: 1},
+		{name: "two values p51", sorted: []time.Duration{1, 2}, p: 51, want: 2},
+		{name: "six values p0", sorted: six, p: 0, want: 1},
+		{name: "six values p50", sorted: six, p: 50, want: 3},
+		{name: "six values p90", sorted: six, p: 90, want: 6},
+		{name: "six values p99", sorted: six, p: 99, want: 6},
+		{name: "six values p100", sorted: six, p: 100, want: 6},
+		{name: "ten values p90", sorted: []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 90, want: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
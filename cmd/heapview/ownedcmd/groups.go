@@ -0,0 +1,152 @@
+package ownedcmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/units"
+)
+
+// group aggregates objects pointed to from roots with the same key. Owned statistics count every object reachable from
+// the group, pointed objects included, once. Retained statistics count objects that would be freed without the group:
+// reachable from it and from no root outside of it, objects shared by pointers of the group included.
+type group struct {
+	key           string
+	pointers      uint64
+	size          uint64
+	ownedSize     uint64
+	ownedCount    uint64
+	retainedSize  uint64
+	retainedCount uint64
+}
+
+// groupings map a grouping name to a function returning the group key of a root, roots without a key are skipped.
+var groupings = map[string]func(h *heap.Heap, root heap.Root) string{
+	"func": func(_ *heap.Heap, root heap.Root) string {
+		if root.Kind != heap.RootFrame {
+			return ""
+		}
+		return root.Name
+	},
+	"package": func(_ *heap.Heap, root heap.Root) string {
+		if root.Kind != heap.RootFrame {
+			return ""
+		}
+		return packageName(root.Name)
+	},
+	"goroutine": func(h *heap.Heap, root heap.Root) string {
+		if root.Kind != heap.RootFrame {
+			return ""
+		}
+		if goroutine, ok := h.Goroutines().OfFrame(root.Addr); ok {
+			return fmt.Sprintf("%d", goroutine.ID)
+		}
+		return "unknown"
+	},
+	"root-kind": func(_ *heap.Heap, root heap.Root) string {
+		return string(root.Kind)
+	},
+}
+
+var groupOrderings = map[string]func(a, b *group) bool{
+	"owned": func(a, b *group) bool { return a.ownedSize > b.ownedSize },
+	"size":  func(a, b *group) bool { return a.size > b.size },
+	"count": func(a, b *group) bool { return a.ownedCount > b.ownedCount },
+}
+
+// packageName strips the function name and receiver from a qualified function name, e.g. "net/http.(*conn).serve"
+// belongs to "net/http".
+func packageName(funcName string) string {
+	slash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[slash+1:], "."); dot >= 0 {
+		return funcName[:slash+1+dot]
+	}
+
+	return funcName
+}
+
+// groupAction writes a table of groups, one row per group.
+func groupAction(h *heap.Heap, opts options) error {
+	g := h.Graph()
+	key := groupings[opts.groupBy]
+
+	groups := map[string]*group{}
+	members := map[string]map[int]struct{}{}
+	// Objects pointed to from roots outside of any group keep what they reach alive regardless of the groups
+	var others []int
+
+	for _, root := range h.Roots().All() {
+		i, ok := g.Index(root.Target)
+		if !ok {
+			continue
+		}
+
+		k := key(h, root)
+		if opts.funcRegexp != nil && (root.Kind != heap.RootFrame || !opts.funcRegexp.MatchString(root.Name)) {
+			k = ""
+		}
+		if k == "" {
+			others = append(others, i)
+			continue
+		}
+
+		gr, ok := groups[k]
+		if !ok {
+			gr = &group{key: k}
+			groups[k] = gr
+			members[k] = map[int]struct{}{}
+		}
+		if _, ok := members[k][i]; !ok {
+			members[k][i] = struct{}{}
+			gr.pointers++
+			gr.size += g.Objects[i].Size
+		}
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	for _, gr := range groups {
+		sorted = append(sorted, gr)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+
+	sets := make([][]int, len(sorted))
+	for k, gr := range sorted {
+		for i := range members[gr.key] {
+			sets[k] = append(sets[k], i)
+		}
+	}
+
+	for k, stats := range g.OwnedGroups(sets) {
+		sorted[k].ownedSize, sorted[k].ownedCount = stats.OwnedSize, stats.OwnedCount
+	}
+	for k, stats := range g.RetainedGroups(sets, others) {
+		sorted[k].retainedSize, sorted[k].retainedCount = stats.OwnedSize, stats.OwnedCount
+	}
+
+	var filtered []*group
+	for _, gr := range sorted {
+		if gr.ownedSize >= opts.minOwned {
+			filtered = append(filtered, gr)
+		}
+	}
+
+	less := groupOrderings[opts.sort]
+	sort.SliceStable(filtered, func(i, j int) bool { return less(filtered[i], filtered[j]) })
+
+	if opts.top > 0 && len(filtered) > opts.top {
+		filtered = filtered[:opts.top]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\tPOINTERS\tSIZE\tOWNED\tOBJECTS\tRETAINED\tOBJECTS\n", strings.ToUpper(opts.groupBy))
+	for _, gr := range filtered {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%s\t%d\n", gr.key, gr.pointers, units.Bytes(gr.size),
+			units.Bytes(gr.ownedSize), gr.ownedCount, units.Bytes(gr.retainedSize), gr.retainedCount)
+	}
+
+	return w.Flush()
+}
@@ -1,28 +1,85 @@
 package ownedcmd
 
 import (
-	"bufio"
-	"encoding/binary"
 	"encoding/json"
-	"errors"
-	"io"
+	"fmt"
 	"os"
+	"regexp"
+	"sort"
 
 	"github.com/urfave/cli/v2"
 
-	"github.com/alexey-medvedchikov/go-heapview/internal/fileutils"
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
 	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
-	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+	"github.com/alexey-medvedchikov/go-heapview/internal/units"
 )
 
 func Command() *cli.Command {
 	return &cli.Command{
 		Name: "owned",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to type objects and name local variables"),
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "Order of pointers: owned, size or count",
+				Value: "owned",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of pointers to report, zero means all",
+			},
+			&cli.StringFlag{
+				Name:  "min-owned",
+				Usage: "Skip pointers owning less than this size, e.g. 64KiB",
+			},
+			&cli.StringFlag{
+				Name:  "func",
+				Usage: "Only report pointers held by frames of functions matching the regular expression",
+			},
+			&cli.StringFlag{
+				Name:  "group-by",
+				Usage: "Aggregate pointers by func, package, goroutine or root-kind into a table",
+			},
+		},
 		Action: func(c *cli.Context) error {
-			fpath := c.Args().Get(0)
-			return fileutils.WithFileOpened(fpath, func(fp *os.File) error {
-				return ownedAction(fp)
-			}, os.O_RDONLY, 0640)
+			opts := options{sort: c.String("sort"), groupBy: c.String("group-by")}
+
+			if _, ok := orderings[opts.sort]; !ok {
+				return fmt.Errorf("unknown sort order: %s", opts.sort)
+			}
+			if _, ok := groupings[opts.groupBy]; opts.groupBy != "" && !ok {
+				return fmt.Errorf("unknown grouping: %s", opts.groupBy)
+			}
+
+			opts.top = c.Int("top")
+
+			if minOwned := c.String("min-owned"); minOwned != "" {
+				var err error
+				if opts.minOwned, err = units.ParseBytes(minOwned); err != nil {
+					return err
+				}
+			}
+
+			if funcPattern := c.String("func"); funcPattern != "" {
+				var err error
+				if opts.funcRegexp, err = regexp.Compile(funcPattern); err != nil {
+					return err
+				}
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			opts.table = table
+			return ownedAction(h, opts)
 		},
 		Usage: "Show pointers that are rooted in stack frames together with the statistics",
 	}
@@ -31,90 +88,145 @@ func Command() *cli.Command {
 type frame struct {
 	Address  heap.Address
 	FuncName string
+	Local    string            `json:",omitempty"`
+	Location *cmdutil.Location `json:",omitempty"`
 }
 
+// pointer is an object pointed to from stack frames. Owned statistics count every object reachable from the object,
+// retained ones count only objects that would be freed without it. Neither includes the object itself, its size is
+// in Size.
 type pointer struct {
-	Address    heap.Address
-	Size       int
-	OwnedSize  int
-	OwnedCount int
-	Frames     []frame
+	Address       heap.Address
+	Size          int
+	Type          string `json:",omitempty"`
+	OwnedSize     int
+	OwnedCount    int
+	RetainedSize  int
+	RetainedCount int
+	Frames        []frame
 }
 
-func ownedAction(r io.Reader) error {
-	encoder := json.NewEncoder(os.Stdout)
+type options struct {
+	table      *symtab.Table
+	sort       string
+	groupBy    string
+	top        int
+	minOwned   uint64
+	funcRegexp *regexp.Regexp
+}
 
-	h, err := readDump(r)
-	if err != nil {
-		return err
+var orderings = map[string]func(a, b pointer) bool{
+	"owned": func(a, b pointer) bool { return a.OwnedSize > b.OwnedSize },
+	"size":  func(a, b pointer) bool { return a.Size > b.Size },
+	"count": func(a, b pointer) bool { return a.OwnedCount > b.OwnedCount },
+}
+
+// frameLocation resolves the PC the frame at frameAddr is suspended at to a source line.
+func frameLocation(h *heap.Heap, table *symtab.Table, frameAddr heap.Address) *cmdutil.Location {
+	fr, ok := h.StackFrames().Get(frameAddr)
+	if !ok {
+		return nil
 	}
 
-	return h.Objects().Walk(func(object heap.Object) error {
-		frames := h.StackFrames().HasAddress(object.Addr)
+	return cmdutil.SymbolizeReturn(table, fr.CurrentPC)
+}
 
-		if len(frames) > 0 {
-			stats := h.Objects().Stats(object.Addr)
+// matchesFunc reports whether any of the frames belongs to a function matching re.
+func matchesFunc(frames []frame, re *regexp.Regexp) bool {
+	for _, fr := range frames {
+		if re.MatchString(fr.FuncName) {
+			return true
+		}
+	}
 
-			p := pointer{
-				Address:    object.Addr,
-				Size:       int(object.Size),
-				OwnedSize:  int(stats.OwnedSize),
-				OwnedCount: int(stats.OwnedCount),
-			}
+	return false
+}
 
-			for _, fr := range frames {
-				p.Frames = append(p.Frames, frame{
-					Address:  fr.Addr,
-					FuncName: fr.FuncName,
-				})
-			}
+func ownedAction(h *heap.Heap, opts options) error {
+	if opts.groupBy != "" {
+		return groupAction(h, opts)
+	}
 
-			if err := encoder.Encode(p); err != nil {
-				return err
-			}
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+
+	// Frames are collected per object, pointers may point inside objects
+	var indexes []int
+	frames := map[int][]frame{}
+	seen := map[int]map[heap.Address]struct{}{}
+	for _, root := range h.Roots().All() {
+		if root.Kind != heap.RootFrame {
+			continue
 		}
 
-		return nil
-	})
-}
+		i, _ := g.Index(root.Target)
+		if _, ok := frames[i]; !ok {
+			indexes = append(indexes, i)
+			seen[i] = map[heap.Address]struct{}{}
+		}
+		if _, ok := seen[i][root.Addr]; ok {
+			continue
+		}
+		seen[i][root.Addr] = struct{}{}
+		frames[i] = append(frames[i], frame{
+			Address:  root.Addr,
+			FuncName: root.Name,
+			Local:    root.Local,
+			Location: frameLocation(h, opts.table, root.Addr),
+		})
+	}
 
-func readDump(r io.Reader) (*heap.Heap, error) {
-	var h *heap.Heap
+	owned := g.Owned(indexes)
+	dominators := h.Dominators()
 
-	endiannessUnknownErr := errors.New("DumpParams missing, endianness unknown")
+	var pointers []pointer
+	for k, i := range indexes {
+		if owned[k].OwnedSize < opts.minOwned {
+			continue
+		}
+		if opts.funcRegexp != nil && !matchesFunc(frames[i], opts.funcRegexp) {
+			continue
+		}
 
-	reader := heapfile.DumpReader{
-		OnDumpParamsFn: func(record heapfile.DumpParams) error {
-			var byteOrder binary.ByteOrder = binary.LittleEndian
-			if record.BigEndian {
-				byteOrder = binary.BigEndian
-			}
-			h = heap.New(byteOrder)
-			return nil
-		},
-		OnObjectFn: func(record heapfile.Object) error {
-			if h == nil {
-				return endiannessUnknownErr
-			}
-			h.Objects().Add(record)
-			return nil
-		},
-		OnStackFrameFn: func(record heapfile.StackFrame) error {
-			if h == nil {
-				return endiannessUnknownErr
-			}
-			h.StackFrames().Add(record)
-			return nil
-		},
-		OnGoroutineFn: func(record heapfile.Goroutine) error {
-			if h == nil {
-				return endiannessUnknownErr
-			}
-			h.Goroutines().Add(record)
-			return nil
-		},
+		object := g.Objects[i]
+		typeDesc, _ := h.Objects().Type(object.Addr)
+		// Retained statistics of the dominator tree include the object, owned ones don't
+		retainedSize, retainedCount := dominators.Retained(i)
+		retainedSize, retainedCount = retainedSize-object.Size, retainedCount-1
+
+		sort.Slice(frames[i], func(a, b int) bool { return frames[i][a].Address < frames[i][b].Address })
+
+		pointers = append(pointers, pointer{
+			Address:       object.Addr,
+			Size:          int(object.Size),
+			Type:          typeDesc.Name,
+			OwnedSize:     int(owned[k].OwnedSize),
+			OwnedCount:    int(owned[k].OwnedCount),
+			RetainedSize:  int(retainedSize),
+			RetainedCount: int(retainedCount),
+			Frames:        frames[i],
+		})
+	}
+
+	// Ties are broken by address to make runs on the same dump comparable
+	less := orderings[opts.sort]
+	sort.Slice(pointers, func(i, j int) bool {
+		if less(pointers[i], pointers[j]) != less(pointers[j], pointers[i]) {
+			return less(pointers[i], pointers[j])
+		}
+		return pointers[i].Address < pointers[j].Address
+	})
+
+	if opts.top > 0 && len(pointers) > opts.top {
+		pointers = pointers[:opts.top]
+	}
+
+	for _, p := range pointers {
+		if err := encoder.Encode(p); err != nil {
+			return err
+		}
 	}
 
-	buffered := bufio.NewReader(r)
-	return h, reader.Read(buffered)
+	return nil
 }
@@ -0,0 +1,232 @@
+package pprofcmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/pprof"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "pprof",
+		ArgsUsage: "DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to name types, globals and source lines of frames"),
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "File to write the profile to",
+				Value:   "heap.pb.gz",
+			},
+			&cli.IntFlag{
+				Name:  "max-depth",
+				Usage: "Number of objects of a dominator path to keep in a stack, deeper objects are added to the last one",
+				Value: 64,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("heap dump file is required")
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			p := newProfile(h, table, c.Int("max-depth"))
+
+			fp, err := os.Create(c.String("output"))
+			if err != nil {
+				return err
+			}
+
+			if err := p.Write(fp); err != nil {
+				_ = fp.Close()
+				return err
+			}
+
+			return fp.Close()
+		},
+		Usage: "Export retained memory as a pprof profile with roots and dominator paths as stacks",
+	}
+}
+
+// node is a stack shared by samples, nodes form a tree with the outermost root frame at the top.
+type node struct {
+	parent   int32
+	location uint64
+	bytes    int64
+	objects  int64
+}
+
+type nodeKey struct {
+	parent   int32
+	location uint64
+}
+
+type builder struct {
+	heap    *heap.Heap
+	table   *symtab.Table
+	profile *pprof.Profile
+	nodes   []node
+	index   map[nodeKey]int32
+	// frames caches stacks of goroutines, innermost frame first
+	frames map[heap.Address][]heap.StackFrame
+}
+
+const noNode = -1
+
+// newProfile makes a sample of every reachable object with its own size, stacks go from the root through dominators
+// of the object, so the cumulative value of a stack entry is the memory retained by it. Objects are named after their
+// type or shape and a run of objects of the same name, e.g. a linked list, is merged into one stack entry, so the same
+// structures of different dumps give the same stacks and can be compared with -diff_base.
+func newProfile(h *heap.Heap, table *symtab.Table, maxDepth int) *pprof.Profile {
+	b := &builder{
+		heap:  h,
+		table: table,
+		profile: pprof.New(
+			pprof.ValueType{Type: "retained_space", Unit: "bytes"},
+			pprof.ValueType{Type: "retained_objects", Unit: "count"},
+		),
+		index:  map[nodeKey]int32{},
+		frames: map[heap.Address][]heap.StackFrame{},
+	}
+
+	g := h.Graph()
+	d := h.Dominators()
+
+	type item struct {
+		object int32
+		parent int32
+		depth  int
+	}
+
+	var stack []item
+	for _, top := range d.Tops() {
+		stack = append(stack, item{object: top, parent: b.rootNode(int(top)), depth: 0})
+	}
+
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		object := g.Objects[it.object]
+		location := b.profile.Location(pprof.Frame{Func: b.objectName(object)})
+
+		current, depth := it.parent, it.depth
+		if b.nodes[current].location != location && depth < maxDepth {
+			current, depth = b.child(current, location), depth+1
+		}
+
+		b.nodes[current].bytes += int64(object.Size)
+		b.nodes[current].objects++
+
+		for _, child := range d.Children(int(it.object)) {
+			stack = append(stack, item{object: child, parent: current, depth: depth})
+		}
+	}
+
+	for i, n := range b.nodes {
+		if n.objects == 0 {
+			continue
+		}
+
+		var locations []uint64
+		for k := int32(i); k != noNode; k = b.nodes[k].parent {
+			locations = append(locations, b.nodes[k].location)
+		}
+		b.profile.Add(locations, n.bytes, n.objects)
+	}
+
+	return b.profile
+}
+
+// child returns the node below parent with the given location, noNode as parent stands for the top of the tree.
+func (b *builder) child(parent int32, location uint64) int32 {
+	key := nodeKey{parent: parent, location: location}
+	if i, ok := b.index[key]; ok {
+		return i
+	}
+
+	i := int32(len(b.nodes))
+	b.nodes = append(b.nodes, node{parent: parent, location: location})
+	b.index[key] = i
+
+	return i
+}
+
+// rootNode returns the node of the root the i-th object is reached from by the shortest path.
+func (b *builder) rootNode(i int) int32 {
+	root, _ := b.heap.Paths().Root(i)
+
+	current := int32(noNode)
+	for _, frame := range b.rootFrames(root) {
+		current = b.child(current, b.profile.Location(frame))
+	}
+
+	return current
+}
+
+// rootFrames describes the root from the outermost frame: stack of the goroutine down to the frame holding the
+// pointer followed by the local variable, the global variable or the kind of the root.
+func (b *builder) rootFrames(root heap.Root) []pprof.Frame {
+	switch root.Kind {
+	case heap.RootFrame:
+		var frames []pprof.Frame
+		if goroutine, ok := b.heap.Goroutines().OfFrame(root.Addr); ok {
+			stack, ok := b.frames[goroutine.Addr]
+			if !ok {
+				stack = b.heap.Goroutines().Frames(goroutine)
+				b.frames[goroutine.Addr] = stack
+			}
+
+			k := len(stack) - 1
+			for k >= 0 && stack[k].Addr != root.Addr {
+				frames = append(frames, b.stackFrame(stack[k]))
+				k--
+			}
+		}
+
+		if frame, ok := b.heap.StackFrames().Get(root.Addr); ok {
+			frames = append(frames, b.stackFrame(frame))
+		}
+		if root.Local != "" {
+			frames = append(frames, pprof.Frame{Func: root.String()})
+		}
+
+		if len(frames) > 0 {
+			return frames
+		}
+	}
+
+	return []pprof.Frame{{Func: root.String()}}
+}
+
+func (b *builder) stackFrame(frame heap.StackFrame) pprof.Frame {
+	result := pprof.Frame{Func: frame.FuncName}
+	if loc := cmdutil.SymbolizeReturn(b.table, frame.CurrentPC); loc != nil {
+		result.File, result.Line = loc.File, int64(loc.Line)
+	}
+
+	return result
+}
+
+func (b *builder) objectName(object heap.Object) string {
+	if typeDesc, ok := b.heap.Objects().Type(object.Addr); ok && typeDesc.Name != "" {
+		return typeDesc.Name
+	}
+
+	return "shape " + string(object.Shape())
+}
@@ -0,0 +1,183 @@
+package scancostcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "scancost",
+		ArgsUsage: "DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to group objects by type"),
+			&cli.StringSliceFlag{
+				Name:  "by",
+				Usage: "Dimensions to group objects by: shape, type, root",
+				Value: cli.NewStringSlice("shape", "type", "root"),
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of groups with the largest mark work to report per dimension",
+				Value: 20,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("heap dump file is required")
+			}
+
+			for _, by := range c.StringSlice("by") {
+				if _, ok := dimensions[by]; !ok {
+					return fmt.Errorf("unknown dimension: %s", by)
+				}
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return scanCostAction(h, c.StringSlice("by"), c.Int("top"))
+		},
+		Usage: "Estimate garbage collector mark work per group of objects to find pointer-dense structures",
+	}
+}
+
+var dimensions = map[string]func(h *heap.Heap, i int) string{
+	"shape": func(h *heap.Heap, i int) string {
+		return string(h.Graph().Objects[i].Shape())
+	},
+	"type": func(h *heap.Heap, i int) string {
+		if typeDesc, ok := h.Objects().Type(h.Graph().Objects[i].Addr); ok {
+			return typeDesc.Name
+		}
+		return "unknown"
+	},
+	"root": func(h *heap.Heap, i int) string {
+		if root, ok := h.Paths().Root(i); ok {
+			return root.String()
+		}
+		return "unreachable"
+	},
+}
+
+type record struct {
+	Type   string
+	Record any
+}
+
+// cost estimates mark work of a set of objects. The collector marks every object and, for objects with pointers,
+// loads and follows every pointer field, so MarkWork is the number of objects plus the number of pointers, and
+// NoScanWork is what the same objects would cost if they had no pointers. ScanBytes is the scanned prefix of objects
+// up to the last pointer field, PointerDensity is the share of pointer words in it.
+type cost struct {
+	Count          uint64
+	Bytes          uint64
+	ScanCount      uint64
+	ScanBytes      uint64
+	NoScanBytes    uint64
+	Pointers       uint64
+	PointerDensity float64
+	MarkWork       uint64
+	NoScanWork     uint64
+}
+
+func (c *cost) add(object heap.Object) {
+	c.Count++
+	c.Bytes += object.Size
+	c.MarkWork++
+	c.NoScanWork++
+
+	if len(object.PointerOffsets) == 0 {
+		c.NoScanBytes += object.Size
+		return
+	}
+
+	c.ScanCount++
+	c.ScanBytes += object.ScanSize()
+	c.Pointers += uint64(len(object.PointerOffsets))
+	c.MarkWork += uint64(len(object.PointerOffsets))
+}
+
+func (c *cost) finish() {
+	if c.ScanBytes > 0 {
+		c.PointerDensity = float64(c.Pointers*8) / float64(c.ScanBytes)
+	}
+}
+
+// group is the cost of objects sharing a key, WorkShare is the share of the total mark work.
+type group struct {
+	By        string
+	Key       string
+	WorkShare float64
+	cost
+}
+
+func scanCostAction(h *heap.Heap, by []string, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+
+	var total cost
+	for _, object := range g.Objects {
+		total.add(object)
+	}
+	total.finish()
+
+	for _, dim := range by {
+		key := dimensions[dim]
+
+		groups := map[string]*group{}
+		for i, object := range g.Objects {
+			k := key(h, i)
+			gr, ok := groups[k]
+			if !ok {
+				gr = &group{By: dim, Key: k}
+				groups[k] = gr
+			}
+			gr.add(object)
+		}
+
+		sorted := make([]*group, 0, len(groups))
+		for _, gr := range groups {
+			gr.finish()
+			if total.MarkWork > 0 {
+				gr.WorkShare = float64(gr.MarkWork) / float64(total.MarkWork)
+			}
+			sorted = append(sorted, gr)
+		}
+
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].MarkWork != sorted[j].MarkWork {
+				return sorted[i].MarkWork > sorted[j].MarkWork
+			}
+			return sorted[i].Key < sorted[j].Key
+		})
+
+		if top > 0 && len(sorted) > top {
+			sorted = sorted[:top]
+		}
+
+		for _, gr := range sorted {
+			if err := encoder.Encode(record{Type: "Group", Record: gr}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return encoder.Encode(record{Type: "Summary", Record: total})
+}
@@ -0,0 +1,158 @@
+package shapescmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+const (
+	maxSamples = 3
+	maxRoots   = 3
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "shapes",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to name shapes with types"),
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "Order of shapes: bytes, count or retained",
+				Value: "bytes",
+			},
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of shapes to report",
+				Value: 20,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			less, ok := orderings[c.String("sort")]
+			if !ok {
+				return fmt.Errorf("unknown sort order: %s", c.String("sort"))
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return shapesAction(h, less, c.Int("top"))
+		},
+		Usage: "Show histogram of object shapes (size and pointer layout) as a type proxy",
+	}
+}
+
+type rootCount struct {
+	Root  string
+	Count int
+}
+
+// shape aggregates objects with the same shape. RetainedBytes doesn't count objects retained by another object of the
+// same shape twice, Types lists type names known for the objects.
+type shape struct {
+	Shape         heap.Shape
+	Count         int
+	Bytes         uint64
+	RetainedBytes uint64
+	Types         []string `json:",omitempty"`
+	Samples       []heap.Address
+	Roots         []rootCount `json:",omitempty"`
+	roots         map[string]int
+	types         map[string]struct{}
+}
+
+var orderings = map[string]func(a, b *shape) bool{
+	"bytes":    func(a, b *shape) bool { return a.Bytes > b.Bytes },
+	"count":    func(a, b *shape) bool { return a.Count > b.Count },
+	"retained": func(a, b *shape) bool { return a.RetainedBytes > b.RetainedBytes },
+}
+
+func shapesAction(h *heap.Heap, less func(a, b *shape) bool, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+	shapes := map[heap.Shape]*shape{}
+	keys := make([]string, g.Len())
+
+	for i, object := range g.Objects {
+		key := object.Shape()
+		keys[i] = string(key)
+
+		s, ok := shapes[key]
+		if !ok {
+			s = &shape{Shape: key, roots: map[string]int{}, types: map[string]struct{}{}}
+			shapes[key] = s
+		}
+
+		s.Count++
+		s.Bytes += object.Size
+		if len(s.Samples) < maxSamples {
+			s.Samples = append(s.Samples, object.Addr)
+		}
+		if root, ok := h.Paths().Root(i); ok {
+			s.roots[root.String()]++
+		}
+		if typeDesc, ok := h.Objects().Type(object.Addr); ok {
+			s.types[typeDesc.Name] = struct{}{}
+		}
+	}
+
+	for key, retained := range h.Dominators().RetainedBy(func(i int) string { return keys[i] }) {
+		shapes[heap.Shape(key)].RetainedBytes = retained
+	}
+
+	sorted := make([]*shape, 0, len(shapes))
+	for _, s := range shapes {
+		sorted = append(sorted, s)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if less(sorted[i], sorted[j]) != less(sorted[j], sorted[i]) {
+			return less(sorted[i], sorted[j])
+		}
+		return sorted[i].Shape < sorted[j].Shape
+	})
+
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+
+	for _, s := range sorted {
+		for root, count := range s.roots {
+			s.Roots = append(s.Roots, rootCount{Root: root, Count: count})
+		}
+		sort.Slice(s.Roots, func(i, j int) bool {
+			if s.Roots[i].Count != s.Roots[j].Count {
+				return s.Roots[i].Count > s.Roots[j].Count
+			}
+			return s.Roots[i].Root < s.Roots[j].Root
+		})
+		if len(s.Roots) > maxRoots {
+			s.Roots = s.Roots[:maxRoots]
+		}
+
+		for name := range s.types {
+			s.Types = append(s.Types, name)
+		}
+		sort.Strings(s.Types)
+
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
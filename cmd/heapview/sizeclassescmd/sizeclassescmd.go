@@ -0,0 +1,162 @@
+package sizeclassescmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/sizeclass"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "sizeclasses",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to type objects and estimate rounding waste"),
+		},
+		Action: func(c *cli.Context) error {
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return sizeClassesAction(h)
+		},
+		Usage: "Bucket objects by runtime size classes and estimate rounding and span fragmentation waste",
+	}
+}
+
+type record struct {
+	Type   string
+	Record any
+}
+
+// classStats is collected per size class, waste fields are estimates: spans are assumed to be densely packed and
+// rounding waste is only known for objects with a type.
+type classStats struct {
+	Class         int
+	ClassSize     uint64
+	Count         uint64
+	Bytes         uint64
+	Spans         uint64
+	SpanTailWaste uint64
+	FreeSlots     uint64
+	TypedCount    uint64
+	RoundingWaste uint64
+}
+
+type largeStats struct {
+	Count         uint64
+	Bytes         uint64
+	TypedCount    uint64
+	RoundingWaste uint64
+}
+
+type summary struct {
+	ObjectCount   uint64
+	ObjectBytes   uint64
+	SpanBytes     uint64
+	HeapAlloc     uint64
+	HeapObjects   uint64
+	HeapInuse     uint64
+	HeapIdle      uint64
+	HeapReleased  uint64
+	InuseUnseen   int64
+	RoundingWaste uint64
+}
+
+func sizeClassesAction(h *heap.Heap) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	classes := sizeclass.All()
+	stats := make([]classStats, len(classes))
+	for i, class := range classes {
+		stats[i] = classStats{Class: class.ID, ClassSize: class.Size}
+	}
+
+	var large largeStats
+
+	err := h.Objects().Walk(func(object heap.Object) error {
+		class := sizeclass.Of(object.Size)
+
+		var rounding uint64
+		typeDesc, typed := h.Objects().Type(object.Addr)
+		typed = typed && typeDesc.Size > 0 && typeDesc.Size <= object.Size
+		if typed {
+			rounding = object.Size - typeDesc.Size
+		}
+
+		if class.ID == 0 {
+			large.Count++
+			large.Bytes += object.Size
+			if typed {
+				large.TypedCount++
+				large.RoundingWaste += rounding
+			}
+			return nil
+		}
+
+		st := &stats[class.ID-1]
+		st.Count++
+		st.Bytes += object.Size
+		if typed {
+			st.TypedCount++
+			st.RoundingWaste += rounding
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var sum summary
+
+	for i, class := range classes {
+		st := &stats[i]
+		if st.Count == 0 {
+			continue
+		}
+
+		st.Spans = (st.Count + class.ObjectsPerSpan() - 1) / class.ObjectsPerSpan()
+		st.SpanTailWaste = st.Spans * class.SpanTail()
+		st.FreeSlots = st.Spans*class.ObjectsPerSpan() - st.Count
+
+		sum.ObjectCount += st.Count
+		sum.ObjectBytes += st.Bytes
+		sum.SpanBytes += st.Spans * class.SpanSize
+		sum.RoundingWaste += st.RoundingWaste
+
+		if err := encoder.Encode(record{Type: "SizeClass", Record: st}); err != nil {
+			return err
+		}
+	}
+
+	if err := encoder.Encode(record{Type: "Large", Record: large}); err != nil {
+		return err
+	}
+
+	sum.ObjectCount += large.Count
+	sum.ObjectBytes += large.Bytes
+	sum.SpanBytes += large.Bytes
+	sum.RoundingWaste += large.RoundingWaste
+
+	if memStats, ok := h.MemStats(); ok {
+		sum.HeapAlloc = memStats.HeapAlloc
+		sum.HeapObjects = memStats.HeapObjects
+		sum.HeapInuse = memStats.HeapInuse
+		sum.HeapIdle = memStats.HeapIdle
+		sum.HeapReleased = memStats.HeapReleased
+		sum.InuseUnseen = int64(memStats.HeapInuse) - int64(sum.SpanBytes)
+	}
+
+	return encoder.Encode(record{Type: "Summary", Record: sum})
+}
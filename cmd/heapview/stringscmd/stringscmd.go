@@ -0,0 +1,197 @@
+package stringscmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+)
+
+// printableRatio is the minimal share of printable runes for a byte sequence to be considered text.
+const printableRatio = 0.9
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name: "strings",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, string headers from its DWARF give exact lengths"),
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of values to report for each ordering",
+				Value: 20,
+			},
+			&cli.IntFlag{
+				Name:  "min-len",
+				Usage: "Minimal length of a string found by scanning object contents",
+				Value: 4,
+			},
+			&cli.IntFlag{
+				Name:  "max-len",
+				Usage: "Values longer than this number of bytes are cut at a rune boundary and marked as truncated",
+				Value: 256,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return stringsAction(h, c.Int("min-len"), c.Int("max-len"), c.Int("top"))
+		},
+		Usage: "Extract printable strings from the heap and show the top values by total bytes and by count",
+	}
+}
+
+// value aggregates occurrences of the same string, Exact is set when at least one occurrence comes from a string
+// header rather than from scanning pointer-free objects. Truncated is set when Value is cut to the maximum length,
+// Length is always the length of the whole string.
+type value struct {
+	By         string
+	Value      string
+	Truncated  bool `json:",omitempty"`
+	Length     int
+	Count      int
+	TotalBytes uint64
+	Exact      bool
+	Owner      heap.Address
+	Path       *cmdutil.Path `json:",omitempty"`
+	owner      int
+}
+
+func stringsAction(h *heap.Heap, minLen, maxLen, top int) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+	values := map[string]*value{}
+	covered := make([]bool, g.Len())
+
+	add := func(s []byte, owner int, exact bool) {
+		v, ok := values[string(s)]
+		if !ok {
+			v = &value{Length: len(s), owner: owner}
+			values[string(s)] = v
+		}
+		// String headers are walked in map order, the lowest object makes the example owner stable between runs
+		if owner < v.owner {
+			v.owner = owner
+		}
+		v.Count++
+		v.TotalBytes += uint64(len(s))
+		v.Exact = v.Exact || exact
+	}
+
+	_ = h.Strings().Walk(func(header heap.StringHeader) error {
+		s, ok := h.Strings().Bytes(header)
+		if !ok || !printable(s) {
+			return nil
+		}
+
+		owner, _ := g.Index(header.Addr)
+		covered[owner] = true
+		add(s, owner, true)
+		return nil
+	})
+
+	for i, object := range g.Objects {
+		if covered[i] || len(object.PointerOffsets) > 0 {
+			continue
+		}
+
+		// Small strings are packed together by the tiny allocator, zero bytes separate them well enough
+		for _, s := range bytes.FieldsFunc(object.Contents, func(r rune) bool { return r == 0 }) {
+			if len(s) >= minLen && printable(s) {
+				add(s, i, false)
+			}
+		}
+	}
+
+	all := make([]string, 0, len(values))
+	for s := range values {
+		all = append(all, s)
+	}
+
+	orderings := []struct {
+		by   string
+		less func(a, b *value) bool
+	}{
+		{by: "bytes", less: func(a, b *value) bool { return a.TotalBytes > b.TotalBytes }},
+		{by: "count", less: func(a, b *value) bool { return a.Count > b.Count }},
+	}
+
+	for _, ordering := range orderings {
+		sort.Slice(all, func(i, j int) bool {
+			a, b := values[all[i]], values[all[j]]
+			if ordering.less(a, b) != ordering.less(b, a) {
+				return ordering.less(a, b)
+			}
+			return all[i] < all[j]
+		})
+
+		for i, s := range all {
+			if top > 0 && i >= top {
+				break
+			}
+
+			v := *values[s]
+			v.By = ordering.by
+			v.Value, v.Truncated = truncate(s, maxLen)
+			v.Owner = g.Objects[v.owner].Addr
+			if path, ok := cmdutil.RetentionPath(h, v.owner); ok {
+				v.Path = &path
+			}
+
+			if err := encoder.Encode(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// truncate cuts s to at most maxLen bytes without splitting a multi-byte rune.
+func truncate(s string, maxLen int) (string, bool) {
+	if len(s) <= maxLen {
+		return s, false
+	}
+
+	n := maxLen
+	if n < 0 {
+		n = 0
+	}
+	for k := 0; n > 0 && k < utf8.UTFMax && !utf8.RuneStart(s[n]); k++ {
+		n--
+	}
+
+	return s[:n], true
+}
+
+// printable reports whether s is valid UTF-8 made mostly of printable runes.
+func printable(s []byte) bool {
+	if len(s) == 0 || !utf8.Valid(s) {
+		return false
+	}
+
+	var total, good int
+	for _, r := range string(s) {
+		total++
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			good++
+		}
+	}
+
+	return float64(good) >= printableRatio*float64(total)
+}
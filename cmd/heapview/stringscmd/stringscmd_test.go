@@ -0,0 +1,31 @@
+package stringscmd
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		maxLen    int
+		want      string
+		truncated bool
+	}{
+		{name: "short", s: "abc", maxLen: 4, want: "abc"},
+		{name: "exact", s: "abcd", maxLen: 4, want: "abcd"},
+		{name: "ascii", s: "abcdef", maxLen: 4, want: "abcd", truncated: true},
+		{name: "rune boundary", s: "abéc", maxLen: 4, want: "abé", truncated: true},
+		{name: "inside two byte rune", s: "abcé", maxLen: 4, want: "abc", truncated: true},
+		{name: "inside three byte rune", s: "a€b", maxLen: 3, want: "a", truncated: true},
+		{name: "inside four byte rune", s: "\U0001f600x", maxLen: 2, want: "", truncated: true},
+		{name: "zero length", s: "abc", maxLen: 0, want: "", truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncate(tt.s, tt.maxLen)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("got %q, %v, want %q, %v", got, truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package suspectscmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/units"
+)
+
+// accumulationRatio is the share of retained memory a single child must hold for the search of an accumulation point
+// to descend into it.
+const accumulationRatio = 0.8
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "suspects",
+		ArgsUsage: "DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to name objects with types"),
+			&cli.Float64Flag{
+				Name:  "min-share",
+				Usage: "Minimal share of the heap, in percent, a suspect must retain",
+				Value: 10,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("heap dump file is required")
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return suspectsAction(h, c.Float64("min-share")/100)
+		},
+		Usage: "Find objects and groups of objects dominating retained memory and where the memory accumulates",
+	}
+}
+
+// object describes a single object of a suspect.
+type object struct {
+	Address       heap.Address
+	Size          uint64
+	Shape         heap.Shape
+	Type          string `json:",omitempty"`
+	RetainedBytes uint64
+	RetainedCount uint64
+}
+
+// accumulation is the object below a suspect where retained memory spreads among many children. ChildShape is the
+// most common shape of the children retaining ChildBytes in ChildCount objects.
+type accumulation struct {
+	object
+	Children   int
+	ChildShape heap.Shape `json:",omitempty"`
+	ChildType  string     `json:",omitempty"`
+	ChildCount int        `json:",omitempty"`
+	ChildBytes uint64     `json:",omitempty"`
+}
+
+// suspect is either a single object or a group of objects with the same shape, each dominated by roots only.
+// Accumulation and Path belong to the biggest object of the suspect.
+type suspect struct {
+	Kind          string
+	Explanation   string
+	Share         float64
+	RetainedBytes uint64
+	Count         int
+	Shape         heap.Shape
+	Type          string `json:",omitempty"`
+	Object        object
+	Accumulation  accumulation
+	Path          *cmdutil.Path `json:",omitempty"`
+}
+
+func suspectsAction(h *heap.Heap, minShare float64) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	g := h.Graph()
+	d := h.Dominators()
+
+	var total uint64
+	for _, o := range g.Objects {
+		total += o.Size
+	}
+	threshold := uint64(minShare * float64(total))
+
+	// Objects dominated by roots only don't retain each other, so their retained sizes can be summed
+	groups := map[heap.Shape][]int{}
+	for _, top := range d.Tops() {
+		shape := g.Objects[top].Shape()
+		groups[shape] = append(groups[shape], int(top))
+	}
+
+	var suspects []suspect
+	for shape, members := range groups {
+		sort.Slice(members, func(i, j int) bool {
+			ri, _ := d.Retained(members[i])
+			rj, _ := d.Retained(members[j])
+			if ri != rj {
+				return ri > rj
+			}
+			return members[i] < members[j]
+		})
+
+		var groupRetained uint64
+		for _, i := range members {
+			retained, _ := d.Retained(i)
+			groupRetained += retained
+		}
+
+		// A group is reported only if no single member is a suspect on its own
+		first, _ := d.Retained(members[0])
+		switch {
+		case first >= threshold:
+			for _, i := range members {
+				if retained, _ := d.Retained(i); retained >= threshold {
+					suspects = append(suspects, newSuspect(h, "object", shape, []int{i}, total))
+				}
+			}
+		case len(members) > 1 && groupRetained >= threshold:
+			suspects = append(suspects, newSuspect(h, "group", shape, members, total))
+		}
+	}
+
+	sort.Slice(suspects, func(i, j int) bool {
+		if suspects[i].RetainedBytes != suspects[j].RetainedBytes {
+			return suspects[i].RetainedBytes > suspects[j].RetainedBytes
+		}
+		return suspects[i].Object.Address < suspects[j].Object.Address
+	})
+
+	for _, s := range suspects {
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newSuspect(h *heap.Heap, kind string, shape heap.Shape, members []int, total uint64) suspect {
+	d := h.Dominators()
+
+	s := suspect{Kind: kind, Count: len(members), Shape: shape}
+	for _, i := range members {
+		retained, _ := d.Retained(i)
+		s.RetainedBytes += retained
+	}
+	s.Share = float64(s.RetainedBytes) / float64(total)
+
+	biggest := members[0]
+	s.Object = describe(h, biggest)
+	s.Type = s.Object.Type
+
+	point := accumulationPoint(d, biggest)
+	s.Accumulation = describeAccumulation(h, point)
+
+	if path, ok := cmdutil.RetentionPath(h, point); ok {
+		s.Path = &path
+	}
+
+	s.Explanation = explain(s, total)
+
+	return s
+}
+
+// accumulationPoint descends the dominator tree from the i-th object while a single child retains most of its memory.
+func accumulationPoint(d *heap.Dominators, i int) int {
+	for {
+		retained, _ := d.Retained(i)
+
+		next := -1
+		for _, child := range d.Children(i) {
+			if childRetained, _ := d.Retained(int(child)); float64(childRetained) >= accumulationRatio*float64(retained) {
+				next = int(child)
+				break
+			}
+		}
+
+		if next < 0 {
+			return i
+		}
+		i = next
+	}
+}
+
+func describe(h *heap.Heap, i int) object {
+	o := h.Graph().Objects[i]
+	retained, count := h.Dominators().Retained(i)
+	typeDesc, _ := h.Objects().Type(o.Addr)
+
+	return object{
+		Address:       o.Addr,
+		Size:          o.Size,
+		Shape:         o.Shape(),
+		Type:          typeDesc.Name,
+		RetainedBytes: retained,
+		RetainedCount: count,
+	}
+}
+
+func describeAccumulation(h *heap.Heap, i int) accumulation {
+	g := h.Graph()
+	d := h.Dominators()
+
+	a := accumulation{object: describe(h, i), Children: len(d.Children(i))}
+
+	type childShape struct {
+		count int
+		bytes uint64
+		first int
+	}
+	shapes := map[heap.Shape]*childShape{}
+	for _, child := range d.Children(i) {
+		shape := g.Objects[child].Shape()
+		cs, ok := shapes[shape]
+		if !ok {
+			cs = &childShape{first: int(child)}
+			shapes[shape] = cs
+		}
+		retained, _ := d.Retained(int(child))
+		cs.count++
+		cs.bytes += retained
+	}
+
+	for shape, cs := range shapes {
+		if cs.count > a.ChildCount || cs.count == a.ChildCount && shape < a.ChildShape {
+			typeDesc, _ := h.Objects().Type(g.Objects[cs.first].Addr)
+			a.ChildShape, a.ChildType, a.ChildCount, a.ChildBytes = shape, typeDesc.Name, cs.count, cs.bytes
+		}
+	}
+
+	return a
+}
+
+func explain(s suspect, total uint64) string {
+	name := string(s.Shape)
+	if s.Type != "" {
+		name = s.Type
+	}
+
+	var text string
+	if s.Kind == "group" {
+		text = fmt.Sprintf("%d objects of %s retain %s (%s of the heap)",
+			s.Count, name, units.Bytes(s.RetainedBytes), units.Percent(s.RetainedBytes, total))
+	} else {
+		text = fmt.Sprintf("object of %s at %#x retains %s (%s of the heap)",
+			name, uint64(s.Object.Address), units.Bytes(s.RetainedBytes), units.Percent(s.RetainedBytes, total))
+	}
+
+	if s.Path != nil {
+		text += fmt.Sprintf(", held by %s", s.Path.Root)
+	}
+
+	a := s.Accumulation
+	if a.ChildCount > 1 {
+		childName := string(a.ChildShape)
+		if a.ChildType != "" {
+			childName = a.ChildType
+		}
+		text += fmt.Sprintf("; memory accumulates in %#x with %d children of %s retaining %s",
+			uint64(a.Address), a.ChildCount, childName, units.Bytes(a.ChildBytes))
+	}
+
+	return text
+}
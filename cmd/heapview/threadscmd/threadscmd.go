@@ -0,0 +1,142 @@
+package threadscmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "threads",
+		ArgsUsage: "DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to resolve go statements to source lines"),
+			&cli.BoolFlag{
+				Name:  "summary",
+				Usage: "Report only thread counts by state and by the go statement of their goroutines",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 1 {
+				return errors.New("heap dump file is required")
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(0), table)
+			if err != nil {
+				return err
+			}
+
+			return threadsAction(h, table, c.Bool("summary"))
+		},
+		Usage: "List OS threads with goroutines on them, threads without one are idle or locked to a parked goroutine",
+	}
+}
+
+type record struct {
+	Type   string
+	Record any
+}
+
+// thread is an OS thread, State is the status of its goroutine or unknown if no goroutine is on it: the dump doesn't
+// tell an idle thread from one locked to a parked goroutine with LockOSThread.
+type thread struct {
+	ID        uint64
+	OSID      uint64
+	Addr      heap.Address
+	State     string
+	Goroutine *goroutine `json:",omitempty"`
+}
+
+type goroutine struct {
+	ID         uint64
+	WaitReason string `json:",omitempty"`
+	Site       string
+	StartFunc  string `json:",omitempty"`
+	TopFunc    string `json:",omitempty"`
+}
+
+// summary counts threads by state and threads with a goroutine by its go statement, a site holding many threads in
+// syscalls or locked ones is where the thread count grows. Unknown counts threads without a goroutine.
+type summary struct {
+	Threads int
+	Unknown int
+	States  map[string]int
+	Sites   map[string]int `json:",omitempty"`
+}
+
+// stateUnknown differs from goroutine status names, "idle" is one of them.
+const stateUnknown = "unknown"
+
+func threadsAction(h *heap.Heap, table *symtab.Table, summaryOnly bool) error {
+	encoder := json.NewEncoder(os.Stdout)
+
+	var threads []thread
+	err := h.OSThreads().Walk(func(t heap.OSThread) error {
+		threads = append(threads, newThread(h, table, t))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(threads, func(i, j int) bool { return threads[i].ID < threads[j].ID })
+
+	s := summary{States: map[string]int{}, Sites: map[string]int{}}
+	for _, t := range threads {
+		s.Threads++
+		s.States[t.State]++
+		if t.Goroutine == nil {
+			s.Unknown++
+			continue
+		}
+		s.Sites[t.Goroutine.Site]++
+	}
+
+	if !summaryOnly {
+		for _, t := range threads {
+			if err := encoder.Encode(record{Type: "Thread", Record: t}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return encoder.Encode(record{Type: "Summary", Record: s})
+}
+
+func newThread(h *heap.Heap, table *symtab.Table, t heap.OSThread) thread {
+	result := thread{ID: t.ID, OSID: t.OSID, Addr: t.Addr, State: stateUnknown}
+
+	g, ok := h.OSThreads().Goroutine(t)
+	if !ok {
+		return result
+	}
+
+	site, _ := cmdutil.GoSite(h, table, g.GoStmtLocation)
+	startFunc, _ := h.Goroutines().StartFunc(g)
+
+	result.State = g.StatusName()
+	result.Goroutine = &goroutine{
+		ID:         g.ID,
+		WaitReason: g.WaitReason,
+		Site:       site,
+		StartFunc:  startFunc,
+	}
+	if frames := h.Goroutines().Frames(g); len(frames) > 0 {
+		result.Goroutine.TopFunc = frames[0].FuncName
+	}
+
+	return result
+}
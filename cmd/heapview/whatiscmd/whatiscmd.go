@@ -0,0 +1,177 @@
+package whatiscmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alexey-medvedchikov/go-heapview/cmd/heapview/cmdutil"
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "whatis",
+		ArgsUsage: "ADDR DUMP",
+		Flags: []cli.Flag{
+			cmdutil.BinaryFlag("Executable the dump was taken from, used to resolve symbols and type objects"),
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() != 2 {
+				return errors.New("address and heap dump file are required")
+			}
+
+			addr, err := strconv.ParseUint(c.Args().Get(0), 0, 64)
+			if err != nil {
+				return fmt.Errorf("invalid address: %w", err)
+			}
+
+			table, err := cmdutil.OpenBinary(c)
+			if err != nil {
+				return err
+			}
+
+			h, err := cmdutil.ReadHeap(c.Args().Get(1), table)
+			if err != nil {
+				return err
+			}
+
+			return whatisAction(h, table, heap.Address(addr))
+		},
+		Usage: "Tell what an address points to: heap object, stack slot, global, type descriptor or OS thread",
+	}
+}
+
+// fact is a single thing the address was found to be, an address can be several things at once, e.g. an object and a
+// goroutine descriptor.
+type fact struct {
+	Kind        string
+	Address     heap.Address
+	Start       heap.Address  `json:",omitempty"`
+	Offset      uint64        `json:",omitempty"`
+	Size        uint64        `json:",omitempty"`
+	Type        string        `json:",omitempty"`
+	Shape       heap.Shape    `json:",omitempty"`
+	Symbol      string        `json:",omitempty"`
+	Local       string        `json:",omitempty"`
+	FuncName    string        `json:",omitempty"`
+	File        string        `json:",omitempty"`
+	Line        int           `json:",omitempty"`
+	Depth       uint64        `json:",omitempty"`
+	GoroutineID uint64        `json:",omitempty"`
+	OSThreadID  uint64        `json:",omitempty"`
+	OSID        uint64        `json:",omitempty"`
+	Path        *cmdutil.Path `json:",omitempty"`
+}
+
+func whatisAction(h *heap.Heap, table *symtab.Table, addr heap.Address) error {
+	var facts []fact
+
+	if object, ok := h.Objects().Find(addr); ok {
+		typeDesc, _ := h.Objects().Type(object.Addr)
+		f := fact{
+			Kind:   "object",
+			Start:  object.Addr,
+			Offset: uint64(addr - object.Addr),
+			Size:   object.Size,
+			Type:   typeDesc.Name,
+			Shape:  object.Shape(),
+		}
+		if i, ok := h.Graph().Index(object.Addr); ok {
+			if path, ok := cmdutil.RetentionPath(h, i); ok {
+				f.Path = &path
+			}
+		}
+		facts = append(facts, f)
+	}
+
+	if goroutine, ok := h.Goroutines().Get(addr); ok {
+		f := fact{Kind: "goroutine", Start: goroutine.Addr, GoroutineID: goroutine.ID}
+		if loc := cmdutil.SymbolizeReturn(table, goroutine.GoStmtLocation); loc != nil {
+			f.FuncName, f.File, f.Line = loc.FuncName, loc.File, loc.Line
+		}
+		facts = append(facts, f)
+	}
+
+	if frame, ok := h.StackFrames().Find(addr); ok {
+		f := fact{
+			Kind:     "stack",
+			Start:    frame.Addr,
+			Offset:   uint64(addr - frame.Addr),
+			Size:     frame.Size,
+			FuncName: frame.FuncName,
+			Depth:    frame.Depth,
+		}
+		if goroutine, ok := h.Goroutines().OfFrame(frame.Addr); ok {
+			f.GoroutineID = goroutine.ID
+		}
+		if loc := cmdutil.SymbolizeReturn(table, frame.CurrentPC); loc != nil {
+			f.File, f.Line = loc.File, loc.Line
+		}
+		if local, ok := h.StackFrames().LocalName(frame.Addr, f.Offset&^7); ok {
+			f.Local = local
+		}
+		facts = append(facts, f)
+	}
+
+	_ = h.Segments().Walk(func(segment heap.Segment) error {
+		end := segment.Addr + heap.Address(len(segment.Contents))
+		if addr < segment.Addr || addr >= end {
+			return nil
+		}
+
+		f := fact{
+			Kind:   string(segment.Kind),
+			Start:  segment.Addr,
+			Offset: uint64(addr - segment.Addr),
+			Size:   uint64(len(segment.Contents)),
+		}
+		if symbol, ok := table.Symbol(uint64(addr)); ok {
+			f.Symbol = fmt.Sprintf("%s+%#x", symbol.Name, uint64(addr)-symbol.Addr)
+		}
+		facts = append(facts, f)
+		return nil
+	})
+
+	if typeDesc, ok := h.TypeDescs().Lookup(addr); ok {
+		kind := "typedesc"
+		if h.TypeDescs().IsItab(addr) {
+			kind = "itab"
+		}
+		facts = append(facts, fact{Kind: kind, Start: addr, Size: typeDesc.Size, Type: typeDesc.Name})
+	}
+
+	if thread, ok := h.OSThreads().Get(addr); ok {
+		facts = append(facts, fact{Kind: "osthread", Start: thread.Addr, OSThreadID: thread.ID, OSID: thread.OSID})
+	}
+
+	if fn, ok := table.Func(uint64(addr)); ok {
+		facts = append(facts, fact{
+			Kind:     "func",
+			Start:    heap.Address(fn.Entry),
+			Offset:   uint64(addr) - fn.Entry,
+			FuncName: fn.Name,
+			File:     fn.File,
+			Line:     fn.Line,
+		})
+	}
+
+	if len(facts) == 0 {
+		facts = append(facts, fact{Kind: "unknown"})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, f := range facts {
+		f.Address = addr
+		if err := encoder.Encode(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
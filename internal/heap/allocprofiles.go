@@ -0,0 +1,62 @@
+package heap
+
+import (
+	"strings"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
+
+type AllocProfiles struct {
+	heap *Heap
+}
+
+func (h *Heap) AllocProfiles() AllocProfiles {
+	return AllocProfiles{heap: h}
+}
+
+func (a AllocProfiles) Add(record heapfile.AllocProfile) {
+	profile := AllocProfile{
+		ID:     record.ID,
+		Size:   record.Size,
+		Allocs: record.Allocs,
+		Frees:  record.Frees,
+	}
+
+	for _, frame := range record.StackFrames {
+		profile.Frames = append(profile.Frames, AllocFrame{
+			FuncName: frame.FuncName,
+			FileName: frame.FileName,
+			Line:     frame.Line,
+		})
+	}
+
+	a.heap.allocProfiles[record.ID] = profile
+}
+
+// AddSample links a sampled object to the allocation profile record it was allocated at.
+func (a AllocProfiles) AddSample(record heapfile.AllocStackSample) {
+	a.heap.allocSamples[Address(record.Address)] = record.ID
+}
+
+// Of returns the allocation profile record of the object at addr, only objects sampled by the memory profiler have
+// one.
+func (a AllocProfiles) Of(addr Address) (AllocProfile, bool) {
+	id, ok := a.heap.allocSamples[addr]
+	if !ok {
+		return AllocProfile{}, false
+	}
+
+	profile, ok := a.heap.allocProfiles[id]
+	return profile, ok
+}
+
+// Site returns the first frame of the allocation stack outside of the runtime package.
+func (p AllocProfile) Site() (AllocFrame, bool) {
+	for _, frame := range p.Frames {
+		if !strings.HasPrefix(frame.FuncName, "runtime.") {
+			return frame, true
+		}
+	}
+
+	return AllocFrame{}, false
+}
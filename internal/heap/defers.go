@@ -0,0 +1,81 @@
+package heap
+
+import "github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+
+type Defers struct {
+	heap *Heap
+}
+
+func (h *Heap) Defers() Defers {
+	return Defers{heap: h}
+}
+
+func (d Defers) Add(record heapfile.Defer) {
+	d.heap.defers[Address(record.Address)] = Defer{
+		Addr:      Address(record.Address),
+		Goroutine: Address(record.Goroutine),
+		Argp:      Address(record.Argp),
+		PC:        record.PC,
+		FuncVal:   Address(record.FuncVal),
+		EntryPC:   record.EntryPC,
+		Next:      Address(record.NextDefer),
+	}
+}
+
+// Chain returns defers starting from the one at top following Next pointers, in the order they will run.
+func (d Defers) Chain(top Address) []Defer {
+	var chain []Defer
+	seen := map[Address]struct{}{}
+
+	for addr := top; addr != 0; {
+		rec, ok := d.heap.defers[addr]
+		if _, isSeen := seen[addr]; !ok || isSeen {
+			break
+		}
+		seen[addr] = struct{}{}
+
+		chain = append(chain, rec)
+		addr = rec.Next
+	}
+
+	return chain
+}
+
+type Panics struct {
+	heap *Heap
+}
+
+func (h *Heap) Panics() Panics {
+	return Panics{heap: h}
+}
+
+func (p Panics) Add(record heapfile.Panic) {
+	p.heap.panics[Address(record.Address)] = Panic{
+		Addr:      Address(record.Address),
+		Goroutine: Address(record.Goroutine),
+		Type:      Address(record.Type),
+		Data:      Address(record.Data),
+		Defer:     Address(record.DeferPointer),
+		Next:      Address(record.NextPanic),
+	}
+}
+
+// Chain returns panics starting from the one at top, the most recent one, following Next pointers to the panics it
+// happened during.
+func (p Panics) Chain(top Address) []Panic {
+	var chain []Panic
+	seen := map[Address]struct{}{}
+
+	for addr := top; addr != 0; {
+		rec, ok := p.heap.panics[addr]
+		if _, isSeen := seen[addr]; !ok || isSeen {
+			break
+		}
+		seen[addr] = struct{}{}
+
+		chain = append(chain, rec)
+		addr = rec.Next
+	}
+
+	return chain
+}
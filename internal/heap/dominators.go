@@ -0,0 +1,288 @@
+package heap
+
+// Dominators is the dominator tree of the object graph. The tree is rooted at a virtual node pointing to every root,
+// so an object is dominated by another object if every path from any root to the object goes through the latter.
+// Retained size of an object is the memory that would be freed if the object was gone.
+type Dominators struct {
+	graph         *Graph
+	idom          []int32
+	order         []int32
+	retained      []uint64
+	retainedCount []uint64
+	childStart    []int32
+	children      []int32
+}
+
+const (
+	virtualRoot   = -1
+	notDominated  = -2
+	undefinedNode = -1
+)
+
+// Dominators builds the tree on the first call with Lengauer-Tarjan algorithm and caches it until new records are
+// added.
+func (h *Heap) Dominators() *Dominators {
+	if h.dominators != nil {
+		return h.dominators
+	}
+
+	g := h.Graph()
+	n := g.Len()
+	root := int32(n)
+
+	var rootTargets []int32
+	seen := make([]bool, n)
+	for _, r := range h.Roots().All() {
+		if target, ok := g.Index(r.Target); ok && !seen[target] {
+			seen[target] = true
+			rootTargets = append(rootTargets, int32(target))
+		}
+	}
+
+	successors := func(v int32) []int32 {
+		if v == root {
+			return rootTargets
+		}
+		return g.Edges(int(v))
+	}
+
+	// Depth-first numbering, dfnum is 1-based, zero means unreachable
+	dfnum := make([]int32, n+1)
+	parent := make([]int32, n+1)
+	vertex := make([]int32, 0, n+1)
+
+	type call struct {
+		node int32
+		edge int
+	}
+
+	dfnum[root] = 1
+	vertex = append(vertex, root)
+	calls := []call{{node: root}}
+	for len(calls) > 0 {
+		top := &calls[len(calls)-1]
+		succ := successors(top.node)
+		if top.edge == len(succ) {
+			calls = calls[:len(calls)-1]
+			continue
+		}
+
+		next := succ[top.edge]
+		top.edge++
+		if dfnum[next] == 0 {
+			vertex = append(vertex, next)
+			dfnum[next] = int32(len(vertex))
+			parent[next] = top.node
+			calls = append(calls, call{node: next})
+		}
+	}
+
+	semi := make([]int32, n+1)
+	idom := make([]int32, n+1)
+	ancestor := make([]int32, n+1)
+	label := make([]int32, n+1)
+	bucketHead := make([]int32, n+1)
+	bucketNext := make([]int32, n+1)
+	for v := range semi {
+		semi[v] = dfnum[v]
+		ancestor[v] = undefinedNode
+		label[v] = int32(v)
+		bucketHead[v] = undefinedNode
+	}
+
+	var path []int32
+	eval := func(v int32) int32 {
+		if ancestor[v] == undefinedNode {
+			return v
+		}
+
+		// Iterative path compression
+		path = path[:0]
+		for u := v; ancestor[ancestor[u]] != undefinedNode; u = ancestor[u] {
+			path = append(path, u)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			u := path[i]
+			a := ancestor[u]
+			if semi[label[a]] < semi[label[u]] {
+				label[u] = label[a]
+			}
+			ancestor[u] = ancestor[a]
+		}
+
+		return label[v]
+	}
+
+	for i := len(vertex) - 1; i >= 1; i-- {
+		w := vertex[i]
+
+		var preds []int32
+		if seen[w] {
+			preds = append(preds, root)
+		}
+		preds = append(preds, g.Referrers(int(w))...)
+
+		for _, v := range preds {
+			if dfnum[v] == 0 {
+				continue
+			}
+			if u := eval(v); semi[u] < semi[w] {
+				semi[w] = semi[u]
+			}
+		}
+
+		s := vertex[semi[w]-1]
+		bucketNext[w] = bucketHead[s]
+		bucketHead[s] = w
+		ancestor[w] = parent[w]
+
+		p := parent[w]
+		for v := bucketHead[p]; v != undefinedNode; v = bucketNext[v] {
+			if u := eval(v); semi[u] < semi[v] {
+				idom[v] = u
+			} else {
+				idom[v] = p
+			}
+		}
+		bucketHead[p] = undefinedNode
+	}
+
+	for _, w := range vertex[1:] {
+		if idom[w] != vertex[semi[w]-1] {
+			idom[w] = idom[idom[w]]
+		}
+	}
+
+	d := &Dominators{
+		graph:         g,
+		idom:          make([]int32, n),
+		order:         vertex[1:],
+		retained:      make([]uint64, n),
+		retainedCount: make([]uint64, n),
+	}
+
+	for v := 0; v < n; v++ {
+		switch {
+		case dfnum[v] == 0:
+			d.idom[v] = notDominated
+		case idom[v] == root:
+			d.idom[v] = virtualRoot
+		default:
+			d.idom[v] = idom[v]
+		}
+	}
+
+	// Dominators precede dominated objects in depth-first order, so the reverse order accumulates subtrees first
+	for i := len(d.order) - 1; i >= 0; i-- {
+		v := d.order[i]
+		d.retained[v] += g.Objects[v].Size
+		d.retainedCount[v]++
+		if p := d.idom[v]; p >= 0 {
+			d.retained[p] += d.retained[v]
+			d.retainedCount[p] += d.retainedCount[v]
+		}
+	}
+
+	h.dominators = d
+	return d
+}
+
+// IDom returns the immediate dominator of the i-th object, false means the object is dominated by roots only or is
+// unreachable.
+func (d *Dominators) IDom(i int) (int, bool) {
+	if d.idom[i] < 0 {
+		return 0, false
+	}
+
+	return int(d.idom[i]), true
+}
+
+// Reachable reports whether the i-th object is reachable from any root.
+func (d *Dominators) Reachable(i int) bool {
+	return d.idom[i] != notDominated
+}
+
+// Retained returns size and number of objects retained by the i-th object, including the object itself.
+func (d *Dominators) Retained(i int) (size, count uint64) {
+	return d.retained[i], d.retainedCount[i]
+}
+
+// Tops returns objects dominated by roots only, they are the top level of the dominator tree.
+func (d *Dominators) Tops() []int32 {
+	d.buildChildren()
+	return d.children[d.childStart[0]:d.childStart[1]]
+}
+
+// Children returns objects immediately dominated by the i-th object.
+func (d *Dominators) Children(i int) []int32 {
+	d.buildChildren()
+	return d.children[d.childStart[i+1]:d.childStart[i+2]]
+}
+
+// buildChildren indexes the tree top-down, children of the virtual root are stored first, so nodes are shifted by one
+// to make room for it.
+func (d *Dominators) buildChildren() {
+	if d.children != nil {
+		return
+	}
+
+	n := len(d.idom)
+
+	d.childStart = make([]int32, n+2)
+	for _, v := range d.order {
+		d.childStart[d.idom[v]+2]++
+	}
+	for i := 1; i < len(d.childStart); i++ {
+		d.childStart[i] += d.childStart[i-1]
+	}
+	d.children = make([]int32, len(d.order))
+	fill := make([]int32, n+1)
+	copy(fill, d.childStart)
+	for _, v := range d.order {
+		p := d.idom[v] + 1
+		d.children[fill[p]] = v
+		fill[p]++
+	}
+}
+
+// RetainedBy sums retained sizes of reachable objects grouped by key without counting the same memory twice: an
+// object is skipped if one of its dominators has the same key. Objects with an empty key are not grouped.
+func (d *Dominators) RetainedBy(key func(i int) string) map[string]uint64 {
+	d.buildChildren()
+	childStart, children := d.childStart, d.children
+
+	result := map[string]uint64{}
+	active := map[string]int{}
+
+	type call struct {
+		node int32
+		key  string
+		next int32
+	}
+
+	calls := []call{{node: virtualRoot, next: childStart[0]}}
+	for len(calls) > 0 {
+		top := &calls[len(calls)-1]
+		if top.next == childStart[top.node+2] {
+			if top.key != "" {
+				active[top.key]--
+			}
+			calls = calls[:len(calls)-1]
+			continue
+		}
+
+		child := children[top.next]
+		top.next++
+
+		k := key(int(child))
+		if k != "" {
+			if active[k] == 0 {
+				result[k] += d.retained[child]
+			}
+			active[k]++
+		}
+		calls = append(calls, call{node: child, key: k, next: childStart[child+1]})
+	}
+
+	return result
+}
@@ -0,0 +1,153 @@
+package heap
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
+
+// testRoots adds a stack frame pointing to the objects with the given numbers of a heap built by testHeap.
+func testRoots(h *Heap, roots ...int) {
+	frame := heapfile.StackFrame{Address: 0x1000, FuncName: "main.main"}
+	for _, i := range roots {
+		word := make([]byte, addressSize)
+		binary.LittleEndian.PutUint64(word, testAddr(i))
+		frame.PointerOffsets = append(frame.PointerOffsets, uint64(len(frame.Contents)))
+		frame.Contents = append(frame.Contents, word...)
+	}
+	h.StackFrames().Add(frame)
+}
+
+// Sizes of objects are 16, 32, 48, 64 and 80 bytes, see testHeap.
+var dominatorTests = []struct {
+	name     string
+	edges    [][]int
+	roots    []int
+	idom     []int // -1 means dominated by roots only or unreachable
+	retained []uint64
+	// unreachable objects have no retained size
+	unreachable []int
+}{
+	{
+		name:     "diamond",
+		edges:    [][]int{{1, 2}, {3}, {3}, {}},
+		roots:    []int{0},
+		idom:     []int{-1, 0, 0, 0},
+		retained: []uint64{160, 32, 48, 64},
+	},
+	{
+		name:     "cycle",
+		edges:    [][]int{{1}, {2}, {1}},
+		roots:    []int{0},
+		idom:     []int{-1, 0, 1},
+		retained: []uint64{96, 80, 48},
+	},
+	{
+		name:        "unreachable",
+		edges:       [][]int{{1}, {}, {1}},
+		roots:       []int{0},
+		idom:        []int{-1, 0, -1},
+		retained:    []uint64{48, 32, 0},
+		unreachable: []int{2},
+	},
+	{
+		name:     "multiple roots",
+		edges:    [][]int{{1}, {3}, {1}, {}},
+		roots:    []int{0, 2},
+		idom:     []int{-1, -1, -1, 1},
+		retained: []uint64{16, 96, 48, 64},
+	},
+	{
+		name:     "loop entered twice",
+		edges:    [][]int{{1, 2}, {3}, {3}, {4}, {1}},
+		roots:    []int{0},
+		idom:     []int{-1, 0, 0, 0, 3},
+		retained: []uint64{240, 32, 48, 144, 80},
+	},
+}
+
+func TestDominators(t *testing.T) {
+	for _, tt := range dominatorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHeap(tt.edges)
+			testRoots(h, tt.roots...)
+			d := h.Dominators()
+
+			unreachable := map[int]bool{}
+			for _, i := range tt.unreachable {
+				unreachable[i] = true
+			}
+
+			for i := range tt.edges {
+				if got := d.Reachable(i); got == unreachable[i] {
+					t.Errorf("object %d: reachable %v", i, got)
+				}
+
+				idom, ok := d.IDom(i)
+				if !ok {
+					idom = -1
+				}
+				if idom != tt.idom[i] {
+					t.Errorf("object %d: idom %d, want %d", i, idom, tt.idom[i])
+				}
+
+				if unreachable[i] {
+					continue
+				}
+				if size, _ := d.Retained(i); size != tt.retained[i] {
+					t.Errorf("object %d: retained %d, want %d", i, size, tt.retained[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetainedBy(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][]int
+		roots []int
+		keys  map[int]string
+		want  map[string]uint64
+	}{
+		{
+			name:  "separate subtrees",
+			edges: [][]int{{1, 2}, {3}, {3}, {}},
+			roots: []int{0},
+			keys:  map[int]string{1: "a", 3: "a"},
+			want:  map[string]uint64{"a": 96},
+		},
+		{
+			name:  "nested",
+			edges: [][]int{{1, 2}, {3}, {3}, {}},
+			roots: []int{0},
+			keys:  map[int]string{0: "a", 3: "a", 2: "b"},
+			want:  map[string]uint64{"a": 160, "b": 48},
+		},
+		{
+			name:  "shared by roots",
+			edges: [][]int{{1}, {3}, {1}, {}},
+			roots: []int{0, 2},
+			keys:  map[int]string{0: "a", 1: "a", 2: "b"},
+			want:  map[string]uint64{"a": 112, "b": 48},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHeap(tt.edges)
+			testRoots(h, tt.roots...)
+
+			got := h.Dominators().RetainedBy(func(i int) string { return tt.keys[i] })
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for key, size := range tt.want {
+				if got[key] != size {
+					t.Errorf("key %q: got %d, want %d", key, got[key], size)
+				}
+			}
+		})
+	}
+}
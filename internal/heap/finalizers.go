@@ -0,0 +1,35 @@
+package heap
+
+import "github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+
+type Finalizers struct {
+	heap *Heap
+}
+
+func (h *Heap) Finalizers() Finalizers {
+	return Finalizers{heap: h}
+}
+
+// Add registers a finalizer, queued finalizers belong to unreachable objects that are waiting for the finalizer
+// goroutine to run them.
+func (f Finalizers) Add(record heapfile.Finalizer, queued bool) {
+	f.heap.finalizers = append(f.heap.finalizers, Finalizer{
+		Object:      Address(record.Address),
+		FuncPointer: Address(record.FuncPointer),
+		EntryPC:     record.EntryPC,
+		ArgType:     Address(record.ArgType),
+		ObjType:     Address(record.ObjType),
+		Queued:      queued,
+	})
+	f.heap.resetIndexes()
+}
+
+func (f Finalizers) Walk(fn func(finalizer Finalizer) error) error {
+	for _, finalizer := range f.heap.finalizers {
+		if err := fn(finalizer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package heap
 
-import "github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+import (
+	"fmt"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
 
 type Goroutines struct {
 	heap *Heap
@@ -11,5 +15,131 @@ func (h *Heap) Goroutines() Goroutines {
 }
 
 func (g Goroutines) Add(record heapfile.Goroutine) {
-	g.heap.goroutines[Address(record.Frame)] = Goroutine{}
+	g.heap.goroutines[Address(record.DescAddress)] = Goroutine{
+		Addr:           Address(record.DescAddress),
+		ID:             record.ID,
+		StackTop:       Address(record.StackTop),
+		GoStmtLocation: record.GoStmtLocation,
+		Status:         record.Status,
+		IsSystem:       record.IsSystem,
+		IsBackground:   record.IsBackground,
+		WaitingSince:   record.WaitingSinceNano,
+		WaitReason:     record.WaitReason,
+		OSThread:       Address(record.OsThreadDesc),
+		TopDefer:       Address(record.TopDefer),
+		TopPanic:       Address(record.TopPanic),
+	}
+	g.heap.resetIndexes()
+}
+
+func (g Goroutines) Walk(fn func(goroutine Goroutine) error) error {
+	for _, goroutine := range g.heap.goroutines {
+		if err := fn(goroutine); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the goroutine with the descriptor at addr.
+func (g Goroutines) Get(addr Address) (Goroutine, bool) {
+	goroutine, ok := g.heap.goroutines[addr]
+	return goroutine, ok
+}
+
+// Frames returns stack frames of the goroutine from the innermost to the outermost one.
+func (g Goroutines) Frames(goroutine Goroutine) []StackFrame {
+	// Callers are indexed on the first call, frames only point to the frame they called
+	if g.heap.frameCallers == nil {
+		g.heap.frameCallers = map[Address]Address{}
+		for _, frame := range g.heap.stackFrames {
+			if frame.ChildPointer != 0 {
+				g.heap.frameCallers[frame.ChildPointer] = frame.Addr
+			}
+		}
+	}
+
+	var frames []StackFrame
+	frame, ok := g.heap.stackFrames[goroutine.StackTop]
+	for ok {
+		frames = append(frames, frame)
+
+		var caller Address
+		if caller, ok = g.heap.frameCallers[frame.Addr]; ok {
+			frame, ok = g.heap.stackFrames[caller]
+		}
+	}
+
+	return frames
+}
+
+// OfFrame returns the goroutine the stack frame at frameAddr belongs to.
+func (g Goroutines) OfFrame(frameAddr Address) (Goroutine, bool) {
+	if g.heap.frameGoroutines == nil {
+		g.heap.frameGoroutines = map[Address]Address{}
+		for _, goroutine := range g.heap.goroutines {
+			for _, frame := range g.Frames(goroutine) {
+				g.heap.frameGoroutines[frame.Addr] = goroutine.Addr
+			}
+		}
+	}
+
+	goroutineAddr, ok := g.heap.frameGoroutines[frameAddr]
+	if !ok {
+		return Goroutine{}, false
+	}
+
+	return g.heap.goroutines[goroutineAddr], true
+}
+
+// statusNames follow runtime goroutine states, unused states are left out.
+var statusNames = map[uint64]string{
+	0: "idle",
+	1: "runnable",
+	2: "running",
+	3: "syscall",
+	4: "waiting",
+	6: "dead",
+	8: "copystack",
+	9: "preempted",
+}
+
+// StatusName names the state of the goroutine, the bit marking goroutines being scanned by GC is ignored.
+func (g Goroutine) StatusName() string {
+	const scanBit = 0x1000
+
+	if name, ok := statusNames[g.Status&^scanBit]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("status %d", g.Status)
+}
+
+// StartFunc returns the name of the function the goroutine was started with, the outermost frame above
+// runtime.goexit.
+func (g Goroutines) StartFunc(goroutine Goroutine) (string, bool) {
+	frames := g.Frames(goroutine)
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].FuncName != "runtime.goexit" {
+			return frames[i].FuncName, true
+		}
+	}
+
+	return "", false
+}
+
+// Defers returns deferred calls of the goroutine in the order they will run.
+func (g Goroutines) Defers(goroutine Goroutine) []Defer {
+	return g.heap.Defers().Chain(goroutine.TopDefer)
+}
+
+// Panics returns active panics of the goroutine from the most recent one.
+func (g Goroutines) Panics(goroutine Goroutine) []Panic {
+	return g.heap.Panics().Chain(goroutine.TopPanic)
+}
+
+// Thread returns the OS thread the goroutine is on.
+func (g Goroutines) Thread(goroutine Goroutine) (OSThread, bool) {
+	return g.heap.OSThreads().Get(goroutine.OSThread)
 }
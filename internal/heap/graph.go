@@ -0,0 +1,97 @@
+package heap
+
+import "sort"
+
+// Graph is a compact form of the object graph. Objects are numbered in address order and every pointer is resolved
+// to the object containing the pointed address, pointers outside the heap are dropped.
+type Graph struct {
+	Objects     []Object
+	edgeStart   []int32
+	edges       []int32
+	edgeOffsets []uint64
+	refStart    []int32
+	refs        []int32
+}
+
+// Graph builds the object graph on the first call and caches it until new records are added.
+func (h *Heap) Graph() *Graph {
+	if h.graph != nil {
+		return h.graph
+	}
+
+	addrs := h.sortedObjectAddrs()
+	g := &Graph{
+		Objects:   make([]Object, len(addrs)),
+		edgeStart: make([]int32, len(addrs)+1),
+	}
+
+	for i, addr := range addrs {
+		g.Objects[i] = h.objects[addr]
+	}
+
+	for i, object := range g.Objects {
+		for j, ptr := range object.Pointers {
+			if target, ok := g.Index(ptr); ok {
+				g.edges = append(g.edges, int32(target))
+				g.edgeOffsets = append(g.edgeOffsets, object.PointerOffsets[j])
+			}
+		}
+		g.edgeStart[i+1] = int32(len(g.edges))
+	}
+
+	h.graph = g
+	return g
+}
+
+func (g *Graph) Len() int {
+	return len(g.Objects)
+}
+
+// Index returns the number of the object containing addr.
+func (g *Graph) Index(addr Address) (int, bool) {
+	i := sort.Search(len(g.Objects), func(i int) bool { return g.Objects[i].Addr > addr }) - 1
+	if i < 0 || addr >= g.Objects[i].Addr+Address(g.Objects[i].Size) {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// Edges returns objects the i-th object points to, an object may be listed several times.
+func (g *Graph) Edges(i int) []int32 {
+	return g.edges[g.edgeStart[i]:g.edgeStart[i+1]]
+}
+
+// EdgeOffsets returns offsets of pointer fields inside the i-th object, aligned with Edges.
+func (g *Graph) EdgeOffsets(i int) []uint64 {
+	return g.edgeOffsets[g.edgeStart[i]:g.edgeStart[i+1]]
+}
+
+// Referrers returns objects pointing to the i-th object, the reverse index is built on the first call.
+func (g *Graph) Referrers(i int) []int32 {
+	if g.refStart == nil {
+		g.buildReferrers()
+	}
+
+	return g.refs[g.refStart[i]:g.refStart[i+1]]
+}
+
+func (g *Graph) buildReferrers() {
+	g.refStart = make([]int32, len(g.Objects)+1)
+	for _, target := range g.edges {
+		g.refStart[target+1]++
+	}
+	for i := 1; i < len(g.refStart); i++ {
+		g.refStart[i] += g.refStart[i-1]
+	}
+
+	g.refs = make([]int32, len(g.edges))
+	fill := make([]int32, len(g.Objects))
+	copy(fill, g.refStart)
+	for i := range g.Objects {
+		for _, target := range g.Edges(i) {
+			g.refs[fill[target]] = int32(i)
+			fill[target]++
+		}
+	}
+}
@@ -2,6 +2,9 @@ package heap
 
 import (
 	"encoding/binary"
+	"sort"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
 )
 
 type Address uint64
@@ -10,37 +13,196 @@ const addressSize = 8
 
 type Heap struct {
 	objects            map[Address]Object
+	objectAddrs        []Address
+	shapes             map[Shape]Shape
+	objectTypes        map[Address]TypeDesc
+	graph              *Graph
+	paths              *Paths
+	dominators         *Dominators
+	assignedTypes      map[Address]TypeDesc
+	stringHeaders      map[StringHeader]struct{}
+	allocProfiles      map[uint64]AllocProfile
+	allocSamples       map[Address]uint64
 	stackFrames        map[Address]StackFrame
+	localNames         map[frameSlot]string
+	globalNames        map[Address]string
 	goroutines         map[Address]Goroutine
+	frameGoroutines    map[Address]Address
+	frameCallers       map[Address]Address
+	threadGoroutines   map[Address]Address
+	osThreads          map[Address]OSThread
+	defers             map[Address]Defer
+	panics             map[Address]Panic
+	segments           []Segment
+	finalizers         []Finalizer
+	otherRoots         []OtherRoot
+	typeDescs          map[Address]TypeDesc
+	itabs              map[Address]Address
 	stackFramePtrIndex map[Address][]Address
+	params             heapfile.DumpParams
+	memStats           *heapfile.MemStats
 	byteOrder          binary.ByteOrder
 }
 
 type Object struct {
-	Pointers []Address
-	Addr     Address
-	Size     uint64
+	Pointers       []Address
+	PointerOffsets []uint64
+	Contents       []byte
+	Addr           Address
+	Size           uint64
+	// shape is computed once when the object is added, objects of the same shape share the string
+	shape Shape
 }
 
 type StackFrame struct {
-	Pointers []Address
+	Pointers       []Address
+	PointerOffsets []uint64
+	Contents       []byte
+	FuncName       string
+	EntryPC        uint64
+	CurrentPC      uint64
+	Depth          uint64
+	ChildPointer   Address
+	Size           uint64
+	Addr           Address
+}
+
+// Goroutine is keyed by the address of its descriptor, StackTop is the address of the innermost stack frame.
+type Goroutine struct {
+	Addr           Address
+	ID             uint64
+	StackTop       Address
+	GoStmtLocation uint64
+	Status         uint64
+	IsSystem       bool
+	IsBackground   bool
+	WaitingSince   uint64
+	WaitReason     string
+	OSThread       Address
+	TopDefer       Address
+	TopPanic       Address
+}
+
+// OSThread is keyed by the address of the runtime M structure.
+type OSThread struct {
+	Addr Address
+	ID   uint64
+	OSID uint64
+}
+
+type AllocFrame struct {
 	FuncName string
-	Size     uint64
-	Addr     Address
+	FileName string
+	Line     uint64
+}
+
+type AllocProfile struct {
+	ID     uint64
+	Size   uint64
+	Frames []AllocFrame
+	Allocs uint64
+	Frees  uint64
+}
+
+type OtherRoot struct {
+	Description string
+	Pointer     Address
+}
+
+// Defer is a deferred call, FuncVal points to the closure with its arguments and Next to the defer of the same
+// goroutine that runs after this one.
+type Defer struct {
+	Addr      Address
+	Goroutine Address
+	Argp      Address
+	PC        uint64
+	FuncVal   Address
+	EntryPC   uint64
+	Next      Address
 }
 
-type Goroutine struct{}
+// Panic is an active panic, Type and Data form the panic value and Next points to the panic it happened during.
+type Panic struct {
+	Addr      Address
+	Goroutine Address
+	Type      Address
+	Data      Address
+	Defer     Address
+	Next      Address
+}
+
+type Finalizer struct {
+	Object      Address
+	FuncPointer Address
+	EntryPC     uint64
+	ArgType     Address
+	ObjType     Address
+	Queued      bool
+}
+
+type SegmentKind string
+
+const (
+	SegmentData SegmentKind = "data"
+	SegmentBSS  SegmentKind = "bss"
+)
+
+type Segment struct {
+	Kind           SegmentKind
+	Addr           Address
+	Contents       []byte
+	PointerOffsets []uint64
+}
+
+type TypeDesc struct {
+	Addr      Address
+	Size      uint64
+	Name      string
+	IsPointer bool
+}
+
+func New(params heapfile.DumpParams) *Heap {
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if params.BigEndian {
+		byteOrder = binary.BigEndian
+	}
 
-func New(byteOrder binary.ByteOrder) *Heap {
 	return &Heap{
+		params:             params,
 		objects:            map[Address]Object{},
+		shapes:             map[Shape]Shape{},
 		stackFrames:        map[Address]StackFrame{},
+		localNames:         map[frameSlot]string{},
+		globalNames:        map[Address]string{},
 		goroutines:         map[Address]Goroutine{},
+		osThreads:          map[Address]OSThread{},
+		defers:             map[Address]Defer{},
+		panics:             map[Address]Panic{},
+		typeDescs:          map[Address]TypeDesc{},
+		assignedTypes:      map[Address]TypeDesc{},
+		stringHeaders:      map[StringHeader]struct{}{},
+		allocProfiles:      map[uint64]AllocProfile{},
+		allocSamples:       map[Address]uint64{},
+		itabs:              map[Address]Address{},
 		stackFramePtrIndex: map[Address][]Address{},
 		byteOrder:          byteOrder,
 	}
 }
 
+// Params returns parameters of the process the dump was taken from.
+func (h *Heap) Params() heapfile.DumpParams {
+	return h.params
+}
+
+// MemStats returns runtime memory statistics recorded in the dump, if any.
+func (h *Heap) MemStats() (heapfile.MemStats, bool) {
+	if h.memStats == nil {
+		return heapfile.MemStats{}, false
+	}
+
+	return *h.memStats, true
+}
+
 func (h *Heap) WalkPointers(start Address, objectFn func(object Object)) {
 	visited := map[Address]struct{}{start: {}}
 	stack := make([]Address, 0, 64*1024/addressSize)
@@ -71,3 +233,53 @@ func (h *Heap) WalkPointers(start Address, objectFn func(object Object)) {
 		}
 	}
 }
+
+// resetIndexes drops everything derived from records, it is called whenever a record is added.
+func (h *Heap) resetIndexes() {
+	h.objectAddrs = nil
+	h.objectTypes = nil
+	h.frameGoroutines = nil
+	h.frameCallers = nil
+	h.threadGoroutines = nil
+	h.graph = nil
+	h.paths = nil
+	h.dominators = nil
+}
+
+// sortedObjectAddrs returns start addresses of all objects in ascending order.
+func (h *Heap) sortedObjectAddrs() []Address {
+	if h.objectAddrs != nil {
+		return h.objectAddrs
+	}
+
+	h.objectAddrs = make([]Address, 0, len(h.objects))
+	for addr := range h.objects {
+		h.objectAddrs = append(h.objectAddrs, addr)
+	}
+	sort.Slice(h.objectAddrs, func(i, j int) bool { return h.objectAddrs[i] < h.objectAddrs[j] })
+
+	return h.objectAddrs
+}
+
+// Word reads a pointer-sized word at addr from object or segment contents recorded in the dump.
+func (h *Heap) Word(addr Address) (Address, bool) {
+	if object, ok := h.Objects().Find(addr); ok {
+		return h.readWord(object.Contents, uint64(addr-object.Addr))
+	}
+
+	for _, segment := range h.segments {
+		if addr >= segment.Addr && addr < segment.Addr+Address(len(segment.Contents)) {
+			return h.readWord(segment.Contents, uint64(addr-segment.Addr))
+		}
+	}
+
+	return 0, false
+}
+
+func (h *Heap) readWord(contents []byte, offset uint64) (Address, bool) {
+	if offset+addressSize > uint64(len(contents)) {
+		return 0, false
+	}
+
+	return Address(h.byteOrder.Uint64(contents[offset:])), true
+}
@@ -1,6 +1,10 @@
 package heap
 
-import "github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+import (
+	"sort"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
 
 type Objects struct {
 	heap *Heap
@@ -22,8 +26,10 @@ func (o Objects) Walk(fn func(object Object) error) error {
 
 func (o Objects) Add(object heapfile.Object) {
 	obj := Object{
-		Addr: Address(object.Address),
-		Size: uint64(len(object.Contents)),
+		Addr:           Address(object.Address),
+		Size:           uint64(len(object.Contents)),
+		PointerOffsets: object.PointerOffsets,
+		Contents:       object.Contents,
 	}
 
 	for _, ptrOffset := range object.PointerOffsets {
@@ -31,7 +37,48 @@ func (o Objects) Add(object heapfile.Object) {
 		obj.Pointers = append(obj.Pointers, Address(ptr))
 	}
 
+	obj.shape = shapeOf(obj.Size, obj.PointerOffsets)
+	if shape, ok := o.heap.shapes[obj.shape]; ok {
+		obj.shape = shape
+	} else {
+		o.heap.shapes[obj.shape] = obj.shape
+	}
+
 	o.heap.objects[Address(object.Address)] = obj
+	o.heap.resetIndexes()
+}
+
+// Find returns the object that contains addr, the address doesn't have to point at the start of the object.
+func (o Objects) Find(addr Address) (Object, bool) {
+	addrs := o.heap.sortedObjectAddrs()
+
+	i := sort.Search(len(addrs), func(i int) bool { return addrs[i] > addr }) - 1
+	if i < 0 {
+		return Object{}, false
+	}
+
+	object := o.heap.objects[addrs[i]]
+	if addr >= object.Addr+Address(object.Size) {
+		return Object{}, false
+	}
+
+	return object, true
+}
+
+// Type returns the object type if it was assigned with SetType or if the object is referenced from an interface value
+// somewhere in the dump. Size of the type is zero when it is unknown.
+func (o Objects) Type(addr Address) (TypeDesc, bool) {
+	if typeDesc, ok := o.heap.assignedTypes[addr]; ok {
+		return typeDesc, true
+	}
+
+	typeDesc, ok := o.heap.interfaceTypes()[addr]
+	return typeDesc, ok
+}
+
+// SetType assigns a type to the object at addr, e.g. one derived from debug information of the binary.
+func (o Objects) SetType(addr Address, typeDesc TypeDesc) {
+	o.heap.assignedTypes[addr] = typeDesc
 }
 
 type ObjectStats struct {
@@ -0,0 +1,56 @@
+package heap
+
+import "github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+
+type OSThreads struct {
+	heap *Heap
+}
+
+func (h *Heap) OSThreads() OSThreads {
+	return OSThreads{heap: h}
+}
+
+func (o OSThreads) Add(record heapfile.OSThread) {
+	o.heap.osThreads[Address(record.Address)] = OSThread{
+		Addr: Address(record.Address),
+		ID:   record.ID,
+		OSID: record.OSID,
+	}
+}
+
+func (o OSThreads) Walk(fn func(thread OSThread) error) error {
+	for _, thread := range o.heap.osThreads {
+		if err := fn(thread); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the thread with the M structure at addr.
+func (o OSThreads) Get(addr Address) (OSThread, bool) {
+	thread, ok := o.heap.osThreads[addr]
+	return thread, ok
+}
+
+// Goroutine returns the goroutine the thread is running. The dump records the thread of a goroutine only while the
+// goroutine is on it, running or in a syscall, so a goroutine locked to the thread with LockOSThread is not found while
+// it is parked and the thread can't be told from an idle one.
+func (o OSThreads) Goroutine(thread OSThread) (Goroutine, bool) {
+	if o.heap.threadGoroutines == nil {
+		o.heap.threadGoroutines = map[Address]Address{}
+		for _, goroutine := range o.heap.goroutines {
+			if goroutine.OSThread != 0 {
+				o.heap.threadGoroutines[goroutine.OSThread] = goroutine.Addr
+			}
+		}
+	}
+
+	goroutineAddr, ok := o.heap.threadGoroutines[thread.Addr]
+	if !ok {
+		return Goroutine{}, false
+	}
+
+	return o.heap.goroutines[goroutineAddr], true
+}
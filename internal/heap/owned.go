@@ -0,0 +1,254 @@
+package heap
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Owned returns statistics of objects reachable from each of the objects with the given numbers, the object itself is
+// never counted, the same way Objects.Stats does. Objects are handled in batches of 64 with one pass over the
+// components of the graph in topological order per batch: every component carries a word with a bit per object of
+// the batch reaching it and passes the word on to components it points to. Shared structures are visited once per
+// batch instead of once per object. Exact counts for all objects in a single pass would need a bit per pair of an
+// object and a component it reaches, which is quadratic in memory, so batches are the trade-off.
+func (g *Graph) Owned(indexes []int) []ObjectStats {
+	comp, count := g.Components()
+
+	// Members of every component are stored contiguously to pass words along edges component by component
+	memberStart, members := componentMembers(comp, count)
+	compStats := make([]ObjectStats, count)
+	for i, c := range comp {
+		compStats[c].OwnedSize += g.Objects[i].Size
+		compStats[c].OwnedCount++
+	}
+
+	// Objects reached from the same components go to the same batch, so a pass starts close to where it ends
+	var distinct []int
+	byIndex := map[int]ObjectStats{}
+	for _, i := range indexes {
+		if _, ok := byIndex[i]; !ok {
+			byIndex[i] = ObjectStats{}
+			distinct = append(distinct, i)
+		}
+	}
+	sort.Slice(distinct, func(a, b int) bool { return comp[distinct[a]] > comp[distinct[b]] })
+
+	reached := make([]uint64, count)
+	own := make([]uint64, count)
+	for batchStart := 0; batchStart < len(distinct); batchStart += 64 {
+		batchEnd := batchStart + 64
+		if batchEnd > len(distinct) {
+			batchEnd = len(distinct)
+		}
+		batch := distinct[batchStart:batchEnd]
+
+		var stats [64]ObjectStats
+		highest := int32(-1)
+		for b, i := range batch {
+			own[comp[i]] |= 1 << b
+			for _, target := range g.Edges(i) {
+				reached[comp[target]] |= 1 << b
+				if comp[target] > highest {
+					highest = comp[target]
+				}
+			}
+		}
+
+		// Edges point from a component to the same or a lower numbered one
+		for c := highest; c >= 0; c-- {
+			mask := reached[c]
+			if mask == 0 {
+				continue
+			}
+			reached[c] = 0
+
+			for _, member := range members[memberStart[c]:memberStart[c+1]] {
+				for _, target := range g.Edges(int(member)) {
+					if comp[target] != c {
+						reached[comp[target]] |= mask
+					}
+				}
+			}
+
+			for m := mask; m != 0; m &= m - 1 {
+				b := bits.TrailingZeros64(m)
+				stats[b].OwnedSize += compStats[c].OwnedSize
+				stats[b].OwnedCount += compStats[c].OwnedCount
+			}
+
+			// An object on a cycle reaches itself but is not counted
+			for m := mask & own[c]; m != 0; m &= m - 1 {
+				b := bits.TrailingZeros64(m)
+				stats[b].OwnedSize -= g.Objects[batch[b]].Size
+				stats[b].OwnedCount--
+			}
+		}
+
+		for b, i := range batch {
+			own[comp[i]] = 0
+			byIndex[i] = stats[b]
+		}
+	}
+
+	result := make([]ObjectStats, len(indexes))
+	for k, i := range indexes {
+		result[k] = byIndex[i]
+	}
+
+	return result
+}
+
+// RetainedGroups returns statistics of objects reachable from every group of objects and from nothing else: neither
+// from another group nor from objects of others, e.g. targets of roots outside of any group. Such objects would be
+// freed without the group, objects of the group are counted too. An object shared by several members of the same
+// group is retained by the group, while the dominator tree gives it to none of them.
+//
+// Every component of the graph is labeled with the only group reaching it, labels are passed along edges in
+// topological order of components and a component reached from two groups or from others is shared.
+func (g *Graph) RetainedGroups(groups [][]int, others []int) []ObjectStats {
+	const (
+		unreached = -1
+		shared    = -2
+	)
+
+	comp, count := g.Components()
+	memberStart, members := componentMembers(comp, count)
+
+	label := make([]int32, count)
+	for c := range label {
+		label[c] = unreached
+	}
+	join := func(c int32, l int32) {
+		switch label[c] {
+		case unreached:
+			label[c] = l
+		case l, shared:
+		default:
+			label[c] = shared
+		}
+	}
+
+	for k, group := range groups {
+		for _, i := range group {
+			join(comp[i], int32(k))
+		}
+	}
+	for _, i := range others {
+		label[comp[i]] = shared
+	}
+
+	// Edges point from a component to the same or a lower numbered one
+	for c := int32(count) - 1; c >= 0; c-- {
+		if label[c] == unreached {
+			continue
+		}
+		for _, member := range members[memberStart[c]:memberStart[c+1]] {
+			for _, target := range g.Edges(int(member)) {
+				if comp[target] != c {
+					join(comp[target], label[c])
+				}
+			}
+		}
+	}
+
+	result := make([]ObjectStats, len(groups))
+	for i, c := range comp {
+		if k := label[c]; k >= 0 {
+			result[k].OwnedSize += g.Objects[i].Size
+			result[k].OwnedCount++
+		}
+	}
+
+	return result
+}
+
+// componentMembers lists objects of every component contiguously, members of the c-th component are
+// members[memberStart[c]:memberStart[c+1]].
+func componentMembers(comp []int32, count int) (memberStart, members []int32) {
+	memberStart = make([]int32, count+1)
+	for _, c := range comp {
+		memberStart[c+1]++
+	}
+	for c := 1; c <= count; c++ {
+		memberStart[c] += memberStart[c-1]
+	}
+
+	members = make([]int32, len(comp))
+	fill := make([]int32, count)
+	copy(fill, memberStart)
+	for i, c := range comp {
+		members[fill[c]] = int32(i)
+		fill[c]++
+	}
+
+	return memberStart, members
+}
+
+// OwnedGroups returns statistics of objects reachable from every group of objects, objects of the group are counted
+// too and an object reachable from several members of the group is counted once.
+func (g *Graph) OwnedGroups(groups [][]int) []ObjectStats {
+	w := newOwnedWalker(g)
+
+	result := make([]ObjectStats, len(groups))
+	for k, group := range groups {
+		for _, i := range group {
+			w.push(int32(i))
+		}
+		result[k] = w.walk()
+	}
+
+	return result
+}
+
+type ownedWalker struct {
+	graph   *Graph
+	visited []uint64
+	touched []int32
+	stack   []int32
+	stats   ObjectStats
+}
+
+func newOwnedWalker(g *Graph) *ownedWalker {
+	return &ownedWalker{
+		graph:   g,
+		visited: make([]uint64, (g.Len()+63)/64),
+		touched: make([]int32, 0, 1024),
+		stack:   make([]int32, 0, 1024),
+	}
+}
+
+func (w *ownedWalker) push(node int32) {
+	word, bit := node/64, uint64(1)<<(node%64)
+	if w.visited[word]&bit != 0 {
+		return
+	}
+	if w.visited[word] == 0 {
+		w.touched = append(w.touched, word)
+	}
+	w.visited[word] |= bit
+
+	w.stats.OwnedSize += w.graph.Objects[node].Size
+	w.stats.OwnedCount++
+	w.stack = append(w.stack, node)
+}
+
+// walk visits everything reachable from pushed objects, returns their statistics and resets the walker.
+func (w *ownedWalker) walk() ObjectStats {
+	for len(w.stack) > 0 {
+		node := w.stack[len(w.stack)-1]
+		w.stack = w.stack[:len(w.stack)-1]
+		for _, target := range w.graph.Edges(int(node)) {
+			w.push(target)
+		}
+	}
+
+	for _, word := range w.touched {
+		w.visited[word] = 0
+	}
+	w.touched = w.touched[:0]
+
+	stats := w.stats
+	w.stats = ObjectStats{}
+
+	return stats
+}
@@ -0,0 +1,227 @@
+package heap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
+
+// benchmarkHeap builds a heap of n objects of 16 bytes linked into a list, optionally closed into a ring, and a stack
+// frame pointing to every step-th object.
+func benchmarkHeap(n, step int, ring bool) *Heap {
+	const base, size = 0xc000000000, 16
+
+	h := New(heapfile.DumpParams{PointerSize: addressSize})
+
+	for i := 0; i < n; i++ {
+		contents := make([]byte, size)
+		var offsets []uint64
+		if next := i + 1; next < n || ring {
+			binary.LittleEndian.PutUint64(contents, uint64(base+next%n*size))
+			offsets = []uint64{0}
+		}
+		h.Objects().Add(heapfile.Object{Address: uint64(base + i*size), Contents: contents, PointerOffsets: offsets})
+	}
+
+	frame := heapfile.StackFrame{Address: 0x1000, FuncName: "main.main"}
+	for i := 0; i < n; i += step {
+		word := make([]byte, addressSize)
+		binary.LittleEndian.PutUint64(word, uint64(base+i*size))
+		frame.PointerOffsets = append(frame.PointerOffsets, uint64(len(frame.Contents)))
+		frame.Contents = append(frame.Contents, word...)
+	}
+	h.StackFrames().Add(frame)
+
+	return h
+}
+
+// testAddr is the address of the i-th object of a heap built by testHeap.
+func testAddr(i int) uint64 {
+	return uint64(0xc000000000 + i*0x1000)
+}
+
+// testHeap builds a heap with an object per entry of edges, the i-th object is 16*(i+1) bytes long, so sums of sizes
+// tell which objects were counted, and points to objects listed in edges[i].
+func testHeap(edges [][]int) *Heap {
+	h := New(heapfile.DumpParams{PointerSize: addressSize})
+	for i, targets := range edges {
+		contents := make([]byte, 16*(i+1))
+		var offsets []uint64
+		for k, target := range targets {
+			binary.LittleEndian.PutUint64(contents[k*addressSize:], testAddr(target))
+			offsets = append(offsets, uint64(k*addressSize))
+		}
+		h.Objects().Add(heapfile.Object{Address: testAddr(i), Contents: contents, PointerOffsets: offsets})
+	}
+
+	return h
+}
+
+func TestOwned(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][]int
+	}{
+		{name: "chain", edges: [][]int{{1}, {2}, {3}, {}}},
+		{name: "diamond", edges: [][]int{{1, 2}, {3}, {3}, {}}},
+		{name: "shared", edges: [][]int{{2}, {2}, {3, 4}, {}, {}}},
+		{name: "ring", edges: [][]int{{1}, {2}, {0}}},
+		{name: "self loop", edges: [][]int{{0, 1}, {}}},
+		{name: "cycle with tail", edges: [][]int{{1}, {2}, {1, 3}, {}}},
+		{name: "nested cycles", edges: [][]int{{1}, {0, 2}, {3}, {2, 4}, {}}},
+		{name: "unreachable", edges: [][]int{{1}, {}, {1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHeap(tt.edges)
+			g := h.Graph()
+
+			indexes := make([]int, g.Len())
+			for i := range indexes {
+				indexes[i] = i
+			}
+
+			owned := g.Owned(indexes)
+			for i, got := range owned {
+				want := h.Objects().Stats(g.Objects[i].Addr)
+				if got != want {
+					t.Errorf("object %d: got %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestOwnedBatches checks objects spread over several batches of 64.
+func TestOwnedBatches(t *testing.T) {
+	for _, ring := range []bool{false, true} {
+		h := benchmarkHeap(500, 3, ring)
+		g := h.Graph()
+
+		indexes := make([]int, g.Len())
+		for i := range indexes {
+			indexes[i] = g.Len() - 1 - i
+		}
+
+		for k, got := range g.Owned(indexes) {
+			want := h.Objects().Stats(g.Objects[indexes[k]].Addr)
+			if got != want {
+				t.Fatalf("ring=%v, object %d: got %+v, want %+v", ring, indexes[k], got, want)
+			}
+		}
+	}
+}
+
+func TestRetainedGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		edges  [][]int
+		groups [][]int
+		others []int
+		// want lists objects retained by every group
+		want [][]int
+	}{
+		{
+			name:   "child shared by members of a group",
+			edges:  [][]int{{2}, {2}, {}, {}},
+			groups: [][]int{{0, 1}},
+			others: []int{3},
+			want:   [][]int{{0, 1, 2}},
+		},
+		{
+			name:   "child shared by groups",
+			edges:  [][]int{{2}, {2}, {}},
+			groups: [][]int{{0}, {1}},
+			want:   [][]int{{0}, {1}},
+		},
+		{
+			name:   "child held by others",
+			edges:  [][]int{{1}, {}, {1}},
+			groups: [][]int{{0}},
+			others: []int{2},
+			want:   [][]int{{0}},
+		},
+		{
+			name:   "cycle",
+			edges:  [][]int{{1}, {2}, {1, 3}, {}},
+			groups: [][]int{{0}},
+			want:   [][]int{{0, 1, 2, 3}},
+		},
+		{
+			name:   "cycle with tail held by others",
+			edges:  [][]int{{1}, {2}, {1, 3}, {}},
+			groups: [][]int{{0}},
+			others: []int{3},
+			want:   [][]int{{0, 1, 2}},
+		},
+		{
+			name:   "cycle shared by groups",
+			edges:  [][]int{{2}, {2}, {3}, {2, 4}, {}},
+			groups: [][]int{{0}, {1}},
+			want:   [][]int{{0}, {1}},
+		},
+		{
+			name:   "member of two groups",
+			edges:  [][]int{{1}, {}},
+			groups: [][]int{{0}, {0}},
+			want:   [][]int{nil, nil},
+		},
+		{
+			name:   "member held by others",
+			edges:  [][]int{{1}, {}},
+			groups: [][]int{{0}},
+			others: []int{0},
+			want:   [][]int{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := testHeap(tt.edges).Graph()
+
+			got := g.RetainedGroups(tt.groups, tt.others)
+			for k, objects := range tt.want {
+				var want ObjectStats
+				for _, i := range objects {
+					want.OwnedSize += g.Objects[i].Size
+					want.OwnedCount++
+				}
+				if got[k] != want {
+					t.Errorf("group %d: got %+v, want %+v", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkOwned(b *testing.B) {
+	for _, ring := range []bool{false, true} {
+		h := benchmarkHeap(20000, 100, ring)
+		g := h.Graph()
+
+		var addrs []Address
+		var indexes []int
+		for _, ptr := range h.stackFrames[0x1000].Pointers {
+			i, _ := g.Index(ptr)
+			addrs = append(addrs, ptr)
+			indexes = append(indexes, i)
+		}
+
+		b.Run(fmt.Sprintf("Stats/ring=%v", ring), func(b *testing.B) {
+			for k := 0; k < b.N; k++ {
+				for _, addr := range addrs {
+					h.Objects().Stats(addr)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Graph/ring=%v", ring), func(b *testing.B) {
+			for k := 0; k < b.N; k++ {
+				g.Owned(indexes)
+			}
+		})
+	}
+}
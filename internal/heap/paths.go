@@ -0,0 +1,104 @@
+package heap
+
+// Paths holds the shortest paths from roots to every reachable object, found with a breadth-first search started
+// from all roots at once. Every object is attributed to a single root, the first one in Roots().All() order among
+// the closest ones.
+type Paths struct {
+	graph        *Graph
+	roots        []Root
+	root         []int32
+	parent       []int32
+	parentOffset []uint64
+}
+
+// PathStep is a single pointer on the path, Offset is the offset of the pointer inside the previous object or
+// inside the root frame or segment for the first step.
+type PathStep struct {
+	Offset uint64
+	Addr   Address
+}
+
+const (
+	noParent  = -1
+	unvisited = -2
+)
+
+// Paths builds paths on the first call and caches them until new records are added.
+func (h *Heap) Paths() *Paths {
+	if h.paths != nil {
+		return h.paths
+	}
+
+	g := h.Graph()
+	p := &Paths{
+		graph:        g,
+		roots:        h.Roots().All(),
+		root:         make([]int32, g.Len()),
+		parent:       make([]int32, g.Len()),
+		parentOffset: make([]uint64, g.Len()),
+	}
+
+	for i := range p.parent {
+		p.parent[i] = unvisited
+	}
+
+	queue := make([]int32, 0, g.Len())
+	for i, root := range p.roots {
+		target, ok := g.Index(root.Target)
+		if !ok || p.parent[target] != unvisited {
+			continue
+		}
+
+		p.parent[target] = noParent
+		p.parentOffset[target] = root.Offset
+		p.root[target] = int32(i)
+		queue = append(queue, int32(target))
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		offsets := g.EdgeOffsets(int(node))
+		for j, target := range g.Edges(int(node)) {
+			if p.parent[target] != unvisited {
+				continue
+			}
+
+			p.parent[target] = node
+			p.parentOffset[target] = offsets[j]
+			p.root[target] = p.root[node]
+			queue = append(queue, target)
+		}
+	}
+
+	h.paths = p
+	return p
+}
+
+// Root returns the root the i-th object of the graph is attributed to, false means the object is unreachable.
+func (p *Paths) Root(i int) (Root, bool) {
+	if p.parent[i] == unvisited {
+		return Root{}, false
+	}
+
+	return p.roots[p.root[i]], true
+}
+
+// Path returns the shortest path from the root to the i-th object of the graph, the last step is the object itself.
+func (p *Paths) Path(i int) []PathStep {
+	if p.parent[i] == unvisited {
+		return nil
+	}
+
+	var path []PathStep
+	for node := int32(i); node != noParent; node = p.parent[node] {
+		path = append(path, PathStep{Offset: p.parentOffset[node], Addr: p.graph.Objects[node].Addr})
+	}
+
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+
+	return path
+}
@@ -0,0 +1,138 @@
+package heap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
+
+var errEndiannessUnknown = errors.New("DumpParams missing, endianness unknown")
+
+// Read parses heap dump from r and builds the heap model out of the records.
+func Read(r io.Reader) (*Heap, error) {
+	var h *Heap
+
+	reader := heapfile.DumpReader{
+		OnDumpParamsFn: func(record heapfile.DumpParams) error {
+			h = New(record)
+			return nil
+		},
+		OnMemStatsFn: func(record heapfile.MemStats) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.memStats = &record
+			return nil
+		},
+		OnObjectFn: func(record heapfile.Object) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Objects().Add(record)
+			return nil
+		},
+		OnStackFrameFn: func(record heapfile.StackFrame) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.StackFrames().Add(record)
+			return nil
+		},
+		OnGoroutineFn: func(record heapfile.Goroutine) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Goroutines().Add(record)
+			return nil
+		},
+		OnFinalizerFn: func(record heapfile.Finalizer) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Finalizers().Add(record, false)
+			return nil
+		},
+		OnQueuedFinalizerFn: func(record heapfile.Finalizer) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Finalizers().Add(record, true)
+			return nil
+		},
+		OnOtherRootFn: func(record heapfile.OtherRoot) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Roots().AddOther(record)
+			return nil
+		},
+		OnAllocProfileFn: func(record heapfile.AllocProfile) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.AllocProfiles().Add(record)
+			return nil
+		},
+		OnAllocStackSampleFn: func(record heapfile.AllocStackSample) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.AllocProfiles().AddSample(record)
+			return nil
+		},
+		OnOSThreadFn: func(record heapfile.OSThread) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.OSThreads().Add(record)
+			return nil
+		},
+		OnDeferFn: func(record heapfile.Defer) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Defers().Add(record)
+			return nil
+		},
+		OnPanicFn: func(record heapfile.Panic) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Panics().Add(record)
+			return nil
+		},
+		OnTypeDescFn: func(record heapfile.TypeDesc) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.TypeDescs().Add(record)
+			return nil
+		},
+		OnItabFn: func(record heapfile.Itab) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.TypeDescs().AddItab(record)
+			return nil
+		},
+		OnDataSegmentFn: func(record heapfile.Segment) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Segments().Add(SegmentData, record)
+			return nil
+		},
+		OnBSSSegmentFn: func(record heapfile.Segment) error {
+			if h == nil {
+				return errEndiannessUnknown
+			}
+			h.Segments().Add(SegmentBSS, record)
+			return nil
+		},
+	}
+
+	buffered := bufio.NewReader(r)
+	return h, reader.Read(buffered)
+}
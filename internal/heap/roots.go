@@ -0,0 +1,137 @@
+package heap
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
+
+type RootKind string
+
+const (
+	RootFrame     RootKind = "frame"
+	RootData      RootKind = "data"
+	RootBSS       RootKind = "bss"
+	RootFinalizer RootKind = "finalizer"
+	RootOther     RootKind = "other"
+)
+
+// Root is a pointer into the heap from outside of it. Addr and Offset locate the pointer: a stack frame or a segment
+// address and the offset inside of it, Name is the function name for frames and the description for other roots.
+// Local is the name of the local variable holding the pointer in a frame, Global is the name of the global variable
+// holding it in a segment, if they are known.
+type Root struct {
+	Kind   RootKind
+	Name   string
+	Local  string
+	Global string
+	Addr   Address
+	Offset uint64
+	Target Address
+}
+
+type Roots struct {
+	heap *Heap
+}
+
+func (h *Heap) Roots() Roots {
+	return Roots{heap: h}
+}
+
+func (r Roots) AddOther(record heapfile.OtherRoot) {
+	r.heap.otherRoots = append(r.heap.otherRoots, OtherRoot{
+		Description: record.Description,
+		Pointer:     Address(record.Pointer),
+	})
+	r.heap.resetIndexes()
+}
+
+// All returns roots pointing to heap objects ordered by kind and location.
+func (r Roots) All() []Root {
+	var roots []Root
+
+	add := func(root Root) {
+		if _, ok := r.heap.Objects().Find(root.Target); ok {
+			roots = append(roots, root)
+		}
+	}
+
+	for _, frame := range r.heap.stackFrames {
+		for i, ptr := range frame.Pointers {
+			local, _ := r.heap.StackFrames().LocalName(frame.Addr, frame.PointerOffsets[i])
+			add(Root{
+				Kind:   RootFrame,
+				Name:   frame.FuncName,
+				Local:  local,
+				Addr:   frame.Addr,
+				Offset: frame.PointerOffsets[i],
+				Target: ptr,
+			})
+		}
+	}
+
+	for _, segment := range r.heap.segments {
+		kind := RootData
+		if segment.Kind == SegmentBSS {
+			kind = RootBSS
+		}
+		for _, ptrOffset := range segment.PointerOffsets {
+			ptr, _ := r.heap.readWord(segment.Contents, ptrOffset)
+			global, _ := r.heap.Segments().GlobalName(segment.Addr + Address(ptrOffset))
+			add(Root{
+				Kind:   kind,
+				Name:   string(segment.Kind),
+				Global: global,
+				Addr:   segment.Addr,
+				Offset: ptrOffset,
+				Target: ptr,
+			})
+		}
+	}
+
+	for _, finalizer := range r.heap.finalizers {
+		add(Root{Kind: RootFinalizer, Target: finalizer.Object})
+	}
+
+	for _, other := range r.heap.otherRoots {
+		add(Root{Kind: RootOther, Name: other.Description, Target: other.Pointer})
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		a, b := roots[i], roots[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Addr != b.Addr {
+			return a.Addr < b.Addr
+		}
+		if a.Offset != b.Offset {
+			return a.Offset < b.Offset
+		}
+		return a.Target < b.Target
+	})
+
+	return roots
+}
+
+// String describes the root: function name and local variable for frames, variable name or segment offset for globals,
+// description for other roots.
+func (r Root) String() string {
+	switch r.Kind {
+	case RootFrame:
+		if r.Local != "" {
+			return fmt.Sprintf("%s: %s", r.Name, r.Local)
+		}
+		return r.Name
+	case RootData, RootBSS:
+		if r.Global != "" {
+			return r.Global
+		}
+		return fmt.Sprintf("%s+%#x", r.Kind, r.Offset)
+	case RootOther:
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	default:
+		return string(r.Kind)
+	}
+}
@@ -0,0 +1,79 @@
+package heap
+
+// Components finds strongly connected components of the graph with Tarjan's algorithm. The algorithm is iterative to
+// not overflow goroutine stack on long pointer chains. It returns the component number of every object and the number
+// of components, components are numbered in reverse topological order.
+func (g *Graph) Components() (comp []int32, count int) {
+	n := len(g.Objects)
+
+	index := make([]int32, n)
+	low := make([]int32, n)
+	comp = make([]int32, n)
+	for i := range comp {
+		comp[i] = -1
+	}
+
+	type call struct {
+		node int32
+		edge int32
+	}
+
+	var stack []int32
+	var calls []call
+	next := int32(1)
+
+	visit := func(node int32) {
+		index[node], low[node] = next, next
+		next++
+		stack = append(stack, node)
+		calls = append(calls, call{node: node, edge: g.edgeStart[node]})
+	}
+
+	for start := 0; start < n; start++ {
+		if index[start] != 0 {
+			continue
+		}
+
+		visit(int32(start))
+
+		for len(calls) > 0 {
+			top := &calls[len(calls)-1]
+			node := top.node
+
+			if top.edge < g.edgeStart[node+1] {
+				target := g.edges[top.edge]
+				top.edge++
+
+				if index[target] == 0 {
+					visit(target)
+				} else if comp[target] == -1 && index[target] < low[node] {
+					// Visited but not assigned to a component yet means the target is on the stack
+					low[node] = index[target]
+				}
+				continue
+			}
+
+			if low[node] == index[node] {
+				for {
+					member := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					comp[member] = int32(count)
+					if member == node {
+						break
+					}
+				}
+				count++
+			}
+
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				parent := calls[len(calls)-1].node
+				if low[node] < low[parent] {
+					low[parent] = low[node]
+				}
+			}
+		}
+	}
+
+	return comp, count
+}
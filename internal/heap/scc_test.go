@@ -0,0 +1,54 @@
+package heap
+
+import "testing"
+
+func TestComponents(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][]int
+		// groups lists objects of every component
+		groups [][]int
+	}{
+		{name: "chain", edges: [][]int{{1}, {2}, {}}, groups: [][]int{{0}, {1}, {2}}},
+		{name: "diamond", edges: [][]int{{1, 2}, {3}, {3}, {}}, groups: [][]int{{0}, {1}, {2}, {3}}},
+		{name: "ring", edges: [][]int{{1}, {2}, {0}}, groups: [][]int{{0, 1, 2}}},
+		{name: "self loop", edges: [][]int{{0, 1}, {}}, groups: [][]int{{0}, {1}}},
+		{name: "linked cycles", edges: [][]int{{1}, {0, 2}, {3}, {2}}, groups: [][]int{{0, 1}, {2, 3}}},
+		{name: "cycle with tails", edges: [][]int{{1}, {2}, {1, 3}, {}, {0}}, groups: [][]int{{0}, {1, 2}, {3}, {4}}},
+		{name: "disconnected", edges: [][]int{{1}, {0}, {}, {2}}, groups: [][]int{{0, 1}, {2}, {3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := testHeap(tt.edges).Graph()
+			comp, count := g.Components()
+
+			if count != len(tt.groups) {
+				t.Fatalf("got %d components, want %d", count, len(tt.groups))
+			}
+
+			group := make([]int, len(tt.edges))
+			for k, members := range tt.groups {
+				for _, i := range members {
+					group[i] = k
+				}
+			}
+			for i := range tt.edges {
+				for j := range tt.edges {
+					if (comp[i] == comp[j]) != (group[i] == group[j]) {
+						t.Errorf("objects %d and %d: components %d and %d", i, j, comp[i], comp[j])
+					}
+				}
+			}
+
+			// Components are numbered in reverse topological order
+			for i, targets := range tt.edges {
+				for _, j := range targets {
+					if comp[i] < comp[j] {
+						t.Errorf("edge %d -> %d goes from component %d to %d", i, j, comp[i], comp[j])
+					}
+				}
+			}
+		})
+	}
+}
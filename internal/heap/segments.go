@@ -0,0 +1,42 @@
+package heap
+
+import "github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+
+type Segments struct {
+	heap *Heap
+}
+
+func (h *Heap) Segments() Segments {
+	return Segments{heap: h}
+}
+
+func (s Segments) Add(kind SegmentKind, segment heapfile.Segment) {
+	s.heap.segments = append(s.heap.segments, Segment{
+		Kind:           kind,
+		Addr:           Address(segment.Address),
+		Contents:       segment.Contents,
+		PointerOffsets: segment.PointerOffsets,
+	})
+	s.heap.resetIndexes()
+}
+
+func (s Segments) Walk(fn func(segment Segment) error) error {
+	for _, segment := range s.heap.segments {
+		if err := fn(segment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetGlobalName names the word at addr of a data or BSS segment, e.g. with a symbol of the binary.
+func (s Segments) SetGlobalName(addr Address, name string) {
+	s.heap.globalNames[addr] = name
+}
+
+// GlobalName returns the name of the word at addr if it was set with SetGlobalName.
+func (s Segments) GlobalName(addr Address) (string, bool) {
+	name, ok := s.heap.globalNames[addr]
+	return name, ok
+}
@@ -0,0 +1,99 @@
+package heap
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Shape fingerprints an object by its size and layout of pointer fields. Objects of the same type have the same
+// shape, so it serves as a type proxy when no type information is available. Pointer offsets are written as
+// progressions where possible, e.g. "8192:8+8*1023" is an array of 1023 pointers after an 8 bytes header.
+type Shape string
+
+// Shape returns the shape of the object computed when it was added to the heap.
+func (o Object) Shape() Shape {
+	if o.shape != "" {
+		return o.shape
+	}
+
+	return shapeOf(o.Size, o.PointerOffsets)
+}
+
+func shapeOf(size uint64, offsets []uint64) Shape {
+	var b strings.Builder
+	b.WriteString(strconv.FormatUint(size, 10))
+
+	for i := 0; i < len(offsets); {
+		if i == 0 {
+			b.WriteByte(':')
+		} else {
+			b.WriteByte(',')
+		}
+
+		j := i + 1
+		if j < len(offsets) {
+			stride := offsets[j] - offsets[i]
+			for j+1 < len(offsets) && offsets[j+1]-offsets[j] == stride {
+				j++
+			}
+
+			if j-i+1 >= 3 {
+				b.WriteString(strconv.FormatUint(offsets[i], 10))
+				b.WriteByte('+')
+				b.WriteString(strconv.FormatUint(stride, 10))
+				b.WriteByte('*')
+				b.WriteString(strconv.Itoa(j - i + 1))
+				i = j + 1
+				continue
+			}
+		}
+
+		b.WriteString(strconv.FormatUint(offsets[i], 10))
+		i++
+	}
+
+	return Shape(b.String())
+}
+
+// ContentHash hashes non-pointer bytes of the object together with its pointer layout. Pointer values are left out,
+// so the hash of an object doesn't change when only its pointers do. Objects pointing to different objects share the
+// hash, whatever the pointed objects hold.
+func (o Object) ContentHash() uint64 {
+	hash := fnv.New64a()
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], o.Size)
+	_, _ = hash.Write(buf[:])
+
+	start := uint64(0)
+	for _, ptrOffset := range o.PointerOffsets {
+		binary.LittleEndian.PutUint64(buf[:], ptrOffset)
+		_, _ = hash.Write(buf[:])
+
+		if ptrOffset > start {
+			_, _ = hash.Write(o.Contents[start:ptrOffset])
+		}
+		start = ptrOffset + addressSize
+	}
+
+	if start < uint64(len(o.Contents)) {
+		_, _ = hash.Write(o.Contents[start:])
+	}
+
+	return hash.Sum64()
+}
+
+// ScanSize is the prefix of the object the garbage collector has to scan, it ends after the last pointer field.
+// Objects without pointers are allocated in noscan spans and are never scanned.
+func (o Object) ScanSize() uint64 {
+	var size uint64
+	for _, offset := range o.PointerOffsets {
+		if offset+addressSize > size {
+			size = offset + addressSize
+		}
+	}
+
+	return size
+}
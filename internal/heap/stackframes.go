@@ -12,9 +12,15 @@ func (h *Heap) StackFrames() StackFrames {
 
 func (s StackFrames) Add(frame heapfile.StackFrame) {
 	fr := StackFrame{
-		FuncName: frame.FuncName,
-		Size:     uint64(len(frame.Contents)),
-		Addr:     Address(frame.Address),
+		FuncName:       frame.FuncName,
+		Size:           uint64(len(frame.Contents)),
+		Addr:           Address(frame.Address),
+		PointerOffsets: frame.PointerOffsets,
+		Contents:       frame.Contents,
+		EntryPC:        frame.EntryPC,
+		CurrentPC:      frame.CurrentPC,
+		Depth:          frame.Depth,
+		ChildPointer:   Address(frame.ChildPointer),
 	}
 
 	for _, ptrOffset := range frame.PointerOffsets {
@@ -24,6 +30,7 @@ func (s StackFrames) Add(frame heapfile.StackFrame) {
 	}
 
 	s.heap.stackFrames[Address(frame.Address)] = fr
+	s.heap.resetIndexes()
 }
 
 func (s StackFrames) HasAddress(addr Address) []StackFrame {
@@ -34,3 +41,70 @@ func (s StackFrames) HasAddress(addr Address) []StackFrame {
 
 	return frames
 }
+
+// FuncName returns the name of the function with entryPC if any of its frames is present in the dump.
+func (s StackFrames) FuncName(entryPC uint64) (string, bool) {
+	for _, frame := range s.heap.stackFrames {
+		if frame.EntryPC == entryPC {
+			return frame.FuncName, true
+		}
+	}
+
+	return "", false
+}
+
+// FuncOf finds the function containing pc using stack frames of the dump: a function contains addresses from its
+// entry up to PCs its frames are stopped at.
+func (s StackFrames) FuncOf(pc uint64) (string, bool) {
+	for _, frame := range s.heap.stackFrames {
+		if pc >= frame.EntryPC && pc <= frame.CurrentPC {
+			return frame.FuncName, true
+		}
+	}
+
+	return "", false
+}
+
+func (s StackFrames) Walk(fn func(frame StackFrame) error) error {
+	for _, frame := range s.heap.stackFrames {
+		if err := fn(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the stack frame at addr.
+func (s StackFrames) Get(addr Address) (StackFrame, bool) {
+	frame, ok := s.heap.stackFrames[addr]
+	return frame, ok
+}
+
+// Find returns the stack frame containing addr.
+func (s StackFrames) Find(addr Address) (StackFrame, bool) {
+	for _, frame := range s.heap.stackFrames {
+		if addr >= frame.Addr && addr < frame.Addr+Address(frame.Size) {
+			return frame, true
+		}
+	}
+
+	return StackFrame{}, false
+}
+
+// frameSlot is a word of a stack frame.
+type frameSlot struct {
+	frame  Address
+	offset uint64
+}
+
+// SetLocalName names the word at offset of the frame at frameAddr, e.g. with a local variable from debug information.
+func (s StackFrames) SetLocalName(frameAddr Address, offset uint64, name string) {
+	s.heap.localNames[frameSlot{frame: frameAddr, offset: offset}] = name
+}
+
+// LocalName returns the name of the word at offset of the frame at frameAddr if it was set with SetLocalName.
+func (s StackFrames) LocalName(frameAddr Address, offset uint64) (string, bool) {
+	name, ok := s.heap.localNames[frameSlot{frame: frameAddr, offset: offset}]
+	return name, ok
+}
@@ -0,0 +1,45 @@
+package heap
+
+// StringHeader is a string value found in typed memory, Addr points to the string bytes inside of a heap object.
+type StringHeader struct {
+	Addr   Address
+	Length uint64
+}
+
+type Strings struct {
+	heap *Heap
+}
+
+func (h *Heap) Strings() Strings {
+	return Strings{heap: h}
+}
+
+// Add records a string header, the same string is recorded once no matter how many headers refer to it.
+func (s Strings) Add(header StringHeader) {
+	s.heap.stringHeaders[header] = struct{}{}
+}
+
+func (s Strings) Walk(fn func(header StringHeader) error) error {
+	for header := range s.heap.stringHeaders {
+		if err := fn(header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Bytes returns contents of the string if it lies within a single object.
+func (s Strings) Bytes(header StringHeader) ([]byte, bool) {
+	object, ok := s.heap.Objects().Find(header.Addr)
+	if !ok {
+		return nil, false
+	}
+
+	start := uint64(header.Addr - object.Addr)
+	if start+header.Length > uint64(len(object.Contents)) {
+		return nil, false
+	}
+
+	return object.Contents[start : start+header.Length], true
+}
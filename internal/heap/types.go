@@ -0,0 +1,102 @@
+package heap
+
+import (
+	"strings"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heapfile"
+)
+
+type TypeDescs struct {
+	heap *Heap
+}
+
+func (h *Heap) TypeDescs() TypeDescs {
+	return TypeDescs{heap: h}
+}
+
+func (t TypeDescs) Add(record heapfile.TypeDesc) {
+	t.heap.typeDescs[Address(record.Address)] = TypeDesc{
+		Addr:      Address(record.Address),
+		Size:      record.Size,
+		Name:      record.Name,
+		IsPointer: record.IsPointer,
+	}
+	t.heap.resetIndexes()
+}
+
+func (t TypeDescs) AddItab(record heapfile.Itab) {
+	t.heap.itabs[Address(record.Address)] = Address(record.TypeDescAddr)
+	t.heap.resetIndexes()
+}
+
+// Lookup resolves the first word of an interface value, which is either a type descriptor address (empty
+// interfaces) or an itab address (non-empty interfaces).
+func (t TypeDescs) Lookup(addr Address) (TypeDesc, bool) {
+	if typeDescAddr, ok := t.heap.itabs[addr]; ok {
+		addr = typeDescAddr
+	}
+
+	typeDesc, ok := t.heap.typeDescs[addr]
+	return typeDesc, ok
+}
+
+// IsItab reports whether addr is the address of an itab rather than of a type descriptor.
+func (t TypeDescs) IsItab(addr Address) bool {
+	_, ok := t.heap.itabs[addr]
+	return ok
+}
+
+// interfaceTypes labels objects referenced from interface values found in objects, stack frames and segments.
+// An interface value is a known itab or type descriptor word followed by a data pointer word.
+func (h *Heap) interfaceTypes() map[Address]TypeDesc {
+	if h.objectTypes != nil {
+		return h.objectTypes
+	}
+
+	h.objectTypes = map[Address]TypeDesc{}
+
+	for _, object := range h.objects {
+		h.labelInterfaces(object.Contents, object.PointerOffsets)
+	}
+
+	for _, frame := range h.stackFrames {
+		h.labelInterfaces(frame.Contents, frame.PointerOffsets)
+	}
+
+	for _, segment := range h.segments {
+		h.labelInterfaces(segment.Contents, segment.PointerOffsets)
+	}
+
+	return h.objectTypes
+}
+
+func (h *Heap) labelInterfaces(contents []byte, ptrOffsets []uint64) {
+	for _, ptrOffset := range ptrOffsets {
+		if ptrOffset < addressSize {
+			continue
+		}
+
+		// The type word isn't a pointer from GC point of view, so it is not listed in pointer offsets
+		typeWord, _ := h.readWord(contents, ptrOffset-addressSize)
+		typeDesc, ok := h.TypeDescs().Lookup(typeWord)
+		if !ok {
+			continue
+		}
+
+		// The data word points either at the start of the object or right after its malloc header
+		dataWord, _ := h.readWord(contents, ptrOffset)
+		object, ok := h.Objects().Find(dataWord)
+		if !ok || dataWord-object.Addr > addressSize {
+			continue
+		}
+
+		// Pointer-shaped values are stored in the data word directly, so the object is the pointee of unknown size
+		if strings.HasPrefix(typeDesc.Name, "*") {
+			typeDesc = TypeDesc{Name: typeDesc.Name[1:]}
+		}
+
+		if prev, ok := h.objectTypes[object.Addr]; !ok || typeDesc.Name < prev.Name {
+			h.objectTypes[object.Addr] = typeDesc
+		}
+	}
+}
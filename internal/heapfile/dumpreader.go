@@ -419,7 +419,7 @@ func readStackFrames(r Reader) ([]Frame, error) {
 			return nil, err
 		}
 
-		result[i].FuncName, err = readString(r)
+		result[i].FileName, err = readString(r)
 		if err != nil {
 			return nil, err
 		}
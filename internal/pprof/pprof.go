@@ -0,0 +1,152 @@
+// Package pprof builds profiles in the format read by go tool pprof, a gzip-compressed protocol buffer described by
+// profile.proto of github.com/google/pprof. Only the parts needed for samples with stacks of named frames are encoded.
+package pprof
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+)
+
+// ValueType describes one of the values of every sample, e.g. "retained_space" measured in "bytes".
+type ValueType struct {
+	Type string
+	Unit string
+}
+
+// Frame is a single entry of a sample stack, File and Line are optional.
+type Frame struct {
+	Func string
+	File string
+	Line int64
+}
+
+type function struct {
+	name string
+	file string
+}
+
+type sample struct {
+	locations []uint64
+	values    []int64
+}
+
+// Profile collects samples, locations, functions and strings are deduplicated as they are added.
+type Profile struct {
+	sampleTypes []ValueType
+	samples     []sample
+	locations   map[Frame]uint64
+	frames      []Frame
+	functions   map[function]uint64
+	funcs       []function
+	strings     map[string]int64
+	stringTable []string
+}
+
+// New creates a profile with samples of the given types, the first type is the one pprof shows by default.
+func New(sampleTypes ...ValueType) *Profile {
+	p := &Profile{
+		sampleTypes: sampleTypes,
+		locations:   map[Frame]uint64{},
+		functions:   map[function]uint64{},
+		strings:     map[string]int64{},
+	}
+	// The first string of the table must be empty
+	p.str("")
+
+	return p
+}
+
+// Location returns the ID of the location of frame, stacks of samples are lists of such IDs.
+func (p *Profile) Location(frame Frame) uint64 {
+	if id, ok := p.locations[frame]; ok {
+		return id
+	}
+
+	id := uint64(len(p.locations) + 1)
+	p.locations[frame] = id
+	p.frames = append(p.frames, frame)
+
+	fn := function{name: frame.Func, file: frame.File}
+	if _, ok := p.functions[fn]; !ok {
+		p.functions[fn] = uint64(len(p.functions) + 1)
+		p.funcs = append(p.funcs, fn)
+	}
+
+	return id
+}
+
+// Add records a sample, stack is a list of location IDs from the leaf to the root and values follow sample types.
+func (p *Profile) Add(stack []uint64, values ...int64) {
+	p.samples = append(p.samples, sample{locations: stack, values: values})
+}
+
+// Write encodes the profile and writes it compressed to w.
+func (p *Profile) Write(w io.Writer) error {
+	// Strings are interned while encoding messages, so the string table is written last
+	var body buffer
+	for _, t := range p.sampleTypes {
+		body.message(1, p.valueType(t))
+	}
+	for _, s := range p.samples {
+		var msg buffer
+		msg.packedUint64(1, s.locations)
+		msg.packedInt64(2, s.values)
+		body.message(2, msg)
+	}
+	for i, frame := range p.frames {
+		var line buffer
+		line.uint64(1, p.functions[function{name: frame.Func, file: frame.File}])
+		line.int64(2, frame.Line)
+
+		var msg buffer
+		msg.uint64(1, uint64(i+1))
+		msg.message(4, line)
+		body.message(4, msg)
+	}
+	for i, fn := range p.funcs {
+		var msg buffer
+		msg.uint64(1, uint64(i+1))
+		msg.int64(2, p.str(fn.name))
+		msg.int64(3, p.str(fn.name))
+		msg.int64(4, p.str(fn.file))
+		body.message(5, msg)
+	}
+	if len(p.sampleTypes) > 0 {
+		body.int64(14, p.str(p.sampleTypes[0].Type))
+	}
+	for _, s := range p.stringTable {
+		body.string(6, s)
+	}
+
+	bw := bufio.NewWriter(w)
+	zw := gzip.NewWriter(bw)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func (p *Profile) valueType(t ValueType) buffer {
+	var msg buffer
+	msg.int64(1, p.str(t.Type))
+	msg.int64(2, p.str(t.Unit))
+	return msg
+}
+
+// str returns the index of s in the string table.
+func (p *Profile) str(s string) int64 {
+	if i, ok := p.strings[s]; ok {
+		return i
+	}
+
+	i := int64(len(p.stringTable))
+	p.strings[s] = i
+	p.stringTable = append(p.stringTable, s)
+
+	return i
+}
@@ -0,0 +1,231 @@
+package pprof
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestProfileLocation(t *testing.T) {
+	p := New(ValueType{Type: "space", Unit: "bytes"})
+
+	first := p.Location(Frame{Func: "main.main", File: "main.go", Line: 10})
+	second := p.Location(Frame{Func: "main.main", File: "main.go", Line: 12})
+	other := p.Location(Frame{Func: "runtime.main"})
+
+	if first != 1 || second != 2 || other != 3 {
+		t.Errorf("got locations %d, %d, %d, want 1, 2, 3", first, second, other)
+	}
+	if again := p.Location(Frame{Func: "main.main", File: "main.go", Line: 10}); again != first {
+		t.Errorf("got location %d for the same frame, want %d", again, first)
+	}
+	if len(p.funcs) != 2 {
+		t.Errorf("got %d functions, want 2", len(p.funcs))
+	}
+}
+
+// decodedProfile holds the fields of a written profile with strings resolved.
+type decodedProfile struct {
+	sampleTypes       []ValueType
+	samples           []sample
+	locations         map[uint64]decodedLine
+	functions         map[uint64]function
+	defaultSampleType string
+}
+
+type decodedLine struct {
+	function uint64
+	line     int64
+}
+
+func TestProfileWrite(t *testing.T) {
+	p := New(ValueType{Type: "retained_space", Unit: "bytes"}, ValueType{Type: "retained_objects", Unit: "count"})
+	leaf := p.Location(Frame{Func: "main.load", File: "main.go", Line: 42})
+	root := p.Location(Frame{Func: "bss main.cache"})
+	p.Add([]uint64{leaf, root}, 4096, 3)
+	p.Add([]uint64{root}, 16, 1)
+
+	var out bytes.Buffer
+	if err := p.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stringTable []string
+	for _, f := range decodeFields(t, data) {
+		if f.num == 6 {
+			stringTable = append(stringTable, string(f.data))
+		}
+	}
+	if len(stringTable) == 0 || stringTable[0] != "" {
+		t.Fatalf("string table %q does not start with an empty string", stringTable)
+	}
+	str := func(i uint64) string {
+		if i >= uint64(len(stringTable)) {
+			t.Fatalf("string %d is out of the table of %d strings", i, len(stringTable))
+		}
+		return stringTable[i]
+	}
+
+	got := decodedProfile{locations: map[uint64]decodedLine{}, functions: map[uint64]function{}}
+	for _, f := range decodeFields(t, data) {
+		switch f.num {
+		case 1:
+			var vt ValueType
+			for _, ff := range decodeFields(t, f.data) {
+				switch ff.num {
+				case 1:
+					vt.Type = str(ff.value)
+				case 2:
+					vt.Unit = str(ff.value)
+				}
+			}
+			got.sampleTypes = append(got.sampleTypes, vt)
+		case 2:
+			var s sample
+			for _, ff := range decodeFields(t, f.data) {
+				switch ff.num {
+				case 1:
+					s.locations = decodePacked(t, ff.data)
+				case 2:
+					for _, v := range decodePacked(t, ff.data) {
+						s.values = append(s.values, int64(v))
+					}
+				}
+			}
+			got.samples = append(got.samples, s)
+		case 4:
+			var id uint64
+			var line decodedLine
+			for _, ff := range decodeFields(t, f.data) {
+				switch ff.num {
+				case 1:
+					id = ff.value
+				case 4:
+					for _, lf := range decodeFields(t, ff.data) {
+						switch lf.num {
+						case 1:
+							line.function = lf.value
+						case 2:
+							line.line = int64(lf.value)
+						}
+					}
+				}
+			}
+			got.locations[id] = line
+		case 5:
+			var id uint64
+			var fn function
+			for _, ff := range decodeFields(t, f.data) {
+				switch ff.num {
+				case 1:
+					id = ff.value
+				case 2:
+					fn.name = str(ff.value)
+				case 3:
+					if name := str(ff.value); name != fn.name {
+						t.Errorf("function %d: system name %q differs from name %q", id, name, fn.name)
+					}
+				case 4:
+					fn.file = str(ff.value)
+				}
+			}
+			got.functions[id] = fn
+		case 14:
+			got.defaultSampleType = str(f.value)
+		}
+	}
+
+	want := decodedProfile{
+		sampleTypes: []ValueType{{Type: "retained_space", Unit: "bytes"}, {Type: "retained_objects", Unit: "count"}},
+		samples: []sample{
+			{locations: []uint64{leaf, root}, values: []int64{4096, 3}},
+			{locations: []uint64{root}, values: []int64{16, 1}},
+		},
+		locations: map[uint64]decodedLine{
+			leaf: {function: 1, line: 42},
+			root: {function: 2},
+		},
+		functions: map[uint64]function{
+			1: {name: "main.load", file: "main.go"},
+			2: {name: "bss main.cache"},
+		},
+		defaultSampleType: "retained_space",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+type field struct {
+	num   int
+	value uint64
+	data  []byte
+}
+
+// decodeFields splits an encoded message into fields, only the wire types written by buffer are supported.
+func decodeFields(t *testing.T, data []byte) []field {
+	t.Helper()
+
+	var fields []field
+	for len(data) > 0 {
+		var key uint64
+		key, data = decodeVarint(t, data)
+
+		f := field{num: int(key >> 3)}
+		switch key & 7 {
+		case wireVarint:
+			f.value, data = decodeVarint(t, data)
+		case wireBytes:
+			var n uint64
+			n, data = decodeVarint(t, data)
+			if n > uint64(len(data)) {
+				t.Fatalf("field %d of %d bytes is past the end of the message", f.num, n)
+			}
+			f.data, data = data[:n], data[n:]
+		default:
+			t.Fatalf("field %d has unexpected wire type %d", f.num, key&7)
+		}
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func decodePacked(t *testing.T, data []byte) []uint64 {
+	t.Helper()
+
+	var vs []uint64
+	for len(data) > 0 {
+		var v uint64
+		v, data = decodeVarint(t, data)
+		vs = append(vs, v)
+	}
+
+	return vs
+}
+
+func decodeVarint(t *testing.T, data []byte) (uint64, []byte) {
+	t.Helper()
+
+	var v uint64
+	for i, b := range data {
+		v |= uint64(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return v, data[i+1:]
+		}
+	}
+	t.Fatal("truncated varint")
+
+	return 0, nil
+}
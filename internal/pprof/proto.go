@@ -0,0 +1,70 @@
+package pprof
+
+// buffer is an encoded protocol buffer message. Fields with zero values are omitted as proto3 does.
+type buffer []byte
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *buffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *buffer) key(field, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *buffer) uint64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *buffer) int64(field int, v int64) {
+	b.uint64(field, uint64(v))
+}
+
+// string is encoded even if it is empty, the string table depends on positions of its entries.
+func (b *buffer) string(field int, s string) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(s)))
+	*b = append(*b, s...)
+}
+
+func (b *buffer) message(field int, msg buffer) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(msg)))
+	*b = append(*b, msg...)
+}
+
+func (b *buffer) packedUint64(field int, vs []uint64) {
+	if len(vs) == 0 {
+		return
+	}
+
+	var packed buffer
+	for _, v := range vs {
+		packed.varint(v)
+	}
+	b.message(field, packed)
+}
+
+func (b *buffer) packedInt64(field int, vs []int64) {
+	if len(vs) == 0 {
+		return
+	}
+
+	var packed buffer
+	for _, v := range vs {
+		packed.varint(uint64(v))
+	}
+	b.message(field, packed)
+}
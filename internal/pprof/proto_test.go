@@ -0,0 +1,56 @@
+package pprof
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	minusOne := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+
+	tests := []struct {
+		name   string
+		encode func(b *buffer)
+		want   []byte
+	}{
+		{name: "varint zero", encode: func(b *buffer) { b.varint(0) }, want: []byte{0x00}},
+		{name: "varint one byte", encode: func(b *buffer) { b.varint(0x7f) }, want: []byte{0x7f}},
+		{name: "varint two bytes", encode: func(b *buffer) { b.varint(300) }, want: []byte{0xac, 0x02}},
+		{name: "varint max", encode: func(b *buffer) { b.varint(^uint64(0)) }, want: minusOne},
+		{name: "key", encode: func(b *buffer) { b.key(1, wireVarint) }, want: []byte{0x08}},
+		{name: "key of large field", encode: func(b *buffer) { b.key(16, wireBytes) }, want: []byte{0x82, 0x01}},
+		{name: "uint64", encode: func(b *buffer) { b.uint64(1, 150) }, want: []byte{0x08, 0x96, 0x01}},
+		{name: "uint64 zero", encode: func(b *buffer) { b.uint64(1, 0) }, want: nil},
+		{name: "int64 negative", encode: func(b *buffer) { b.int64(2, -1) }, want: append([]byte{0x10}, minusOne...)},
+		{name: "string", encode: func(b *buffer) { b.string(6, "ab") }, want: []byte{0x32, 0x02, 'a', 'b'}},
+		{name: "string empty", encode: func(b *buffer) { b.string(6, "") }, want: []byte{0x32, 0x00}},
+		{
+			name:   "message",
+			encode: func(b *buffer) { b.message(4, buffer{0x08, 0x01}) },
+			want:   []byte{0x22, 0x02, 0x08, 0x01},
+		},
+		{name: "message empty", encode: func(b *buffer) { b.message(4, nil) }, want: []byte{0x22, 0x00}},
+		{
+			name:   "packed uint64",
+			encode: func(b *buffer) { b.packedUint64(1, []uint64{1, 300}) },
+			want:   []byte{0x0a, 0x03, 0x01, 0xac, 0x02},
+		},
+		{name: "packed uint64 empty", encode: func(b *buffer) { b.packedUint64(1, nil) }, want: nil},
+		{
+			name:   "packed int64",
+			encode: func(b *buffer) { b.packedInt64(2, []int64{2, -1}) },
+			want:   append([]byte{0x12, 0x0b, 0x02}, minusOne...),
+		},
+		{name: "packed int64 empty", encode: func(b *buffer) { b.packedInt64(2, nil) }, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b buffer
+			tt.encode(&b)
+			if !bytes.Equal(b, tt.want) {
+				t.Errorf("got % x, want % x", []byte(b), tt.want)
+			}
+		})
+	}
+}
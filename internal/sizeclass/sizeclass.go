@@ -0,0 +1,74 @@
+// Package sizeclass mirrors allocation size classes of the Go runtime, see runtime/sizeclasses.go.
+package sizeclass
+
+import "sort"
+
+const (
+	// MaxSmallSize is the largest object size served from a size class, larger objects get their own span.
+	MaxSmallSize = 32768
+	// PageSize is the runtime page size, spans are multiples of it.
+	PageSize = 8192
+)
+
+var classToSize = [...]uint64{0, 8, 16, 24, 32, 48, 64, 80, 96, 112, 128, 144, 160, 176, 192, 208, 224, 240, 256, 288,
+	320, 352, 384, 416, 448, 480, 512, 576, 640, 704, 768, 896, 1024, 1152, 1280, 1408, 1536, 1792, 2048, 2304, 2688,
+	3072, 3200, 3456, 4096, 4864, 5376, 6144, 6528, 6784, 6912, 8192, 9472, 9728, 10240, 10880, 12288, 13568, 14336,
+	16384, 18432, 19072, 20480, 21760, 24576, 27264, 28672, 32768}
+
+var classToNPages = [...]uint64{0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 2, 1, 2, 1, 3, 2, 3, 1, 3, 2, 3, 4, 5, 6, 1, 7, 6, 5, 4, 3, 5, 7, 2, 9, 7, 5, 8, 3, 10, 7, 4}
+
+// Class describes a single size class, ID is zero for large objects.
+type Class struct {
+	ID       int
+	Size     uint64
+	SpanSize uint64
+}
+
+// ObjectsPerSpan returns how many objects of the class fit into one span.
+func (c Class) ObjectsPerSpan() uint64 {
+	if c.Size == 0 {
+		return 0
+	}
+
+	return c.SpanSize / c.Size
+}
+
+// SpanTail returns the number of bytes at the end of each span that can't hold an object.
+func (c Class) SpanTail() uint64 {
+	return c.SpanSize - c.ObjectsPerSpan()*c.Size
+}
+
+// All returns every small object size class in ascending order.
+func All() []Class {
+	classes := make([]Class, 0, len(classToSize)-1)
+	for id := 1; id < len(classToSize); id++ {
+		classes = append(classes, class(id))
+	}
+
+	return classes
+}
+
+// Of returns the smallest size class that fits size bytes. Objects larger than MaxSmallSize are allocated in
+// dedicated spans rounded up to PageSize, for those the returned class has zero ID.
+func Of(size uint64) Class {
+	if size > MaxSmallSize {
+		spanSize := (size + PageSize - 1) / PageSize * PageSize
+		return Class{Size: spanSize, SpanSize: spanSize}
+	}
+
+	id := sort.Search(len(classToSize), func(i int) bool { return classToSize[i] >= size })
+	if id == 0 {
+		id = 1
+	}
+
+	return class(id)
+}
+
+func class(id int) Class {
+	return Class{
+		ID:       id,
+		Size:     classToSize[id],
+		SpanSize: classToNPages[id] * PageSize,
+	}
+}
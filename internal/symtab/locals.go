@@ -0,0 +1,484 @@
+package symtab
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Local is a part of a local variable or a parameter stored in a stack frame. Offset is relative to the canonical
+// frame address, the end of the frame in Go heap dumps, VarOffset is the offset of the part inside the variable. A
+// variable is split into parts when the compiler keeps some of its fields in registers.
+type Local struct {
+	Name      string
+	Type      dwarf.Type
+	Offset    int64
+	Size      int64
+	VarOffset int64
+}
+
+// funcLocals holds variables of a function as they are described in DWARF, their locations are decoded for a
+// particular PC on lookup.
+type funcLocals struct {
+	vars     []localVar
+	lowPC    uint64
+	addrBase int64
+}
+
+type localVar struct {
+	name     string
+	typ      dwarf.Type
+	location *dwarf.Field
+	// ranges limit variables of lexical blocks, nil means the whole function
+	ranges [][2]uint64
+}
+
+// Locals returns variables of the function starting at entryPC that are stored in its frame when it executes pc.
+func (t *Table) Locals(entryPC, pc uint64) ([]Local, error) {
+	if !t.HasDWARF() {
+		return nil, nil
+	}
+
+	fn, err := t.funcLocals(entryPC)
+	if err != nil || fn == nil {
+		return nil, err
+	}
+
+	var locals []Local
+	for _, v := range fn.vars {
+		if v.ranges != nil && !inRanges(v.ranges, pc) {
+			continue
+		}
+
+		expr, ok := t.locationExpr(fn, v.location, pc)
+		if !ok {
+			continue
+		}
+
+		for _, p := range stackPieces(expr, v.typ.Size()) {
+			locals = append(locals, Local{Name: v.name, Type: v.typ, Offset: p.offset, Size: p.size, VarOffset: p.varOffset})
+		}
+	}
+
+	return locals, nil
+}
+
+// LocalName names the stack slot at offset from the canonical frame address of the function starting at entryPC, e.g.
+// "client.cache" for a field of a struct variable.
+func (t *Table) LocalName(entryPC, pc uint64, offset int64) (string, bool) {
+	locals, err := t.Locals(entryPC, pc)
+	if err != nil {
+		return "", false
+	}
+
+	for _, local := range locals {
+		if offset >= local.Offset && offset < local.Offset+local.Size {
+			return local.Name + fieldPath(local.Type, local.VarOffset+offset-local.Offset), true
+		}
+	}
+
+	return "", false
+}
+
+// funcLocals reads variables of the function starting at entryPC, results are cached per function.
+func (t *Table) funcLocals(entryPC uint64) (*funcLocals, error) {
+	if fn, ok := t.locals[entryPC]; ok {
+		return fn, nil
+	}
+	if t.locals == nil {
+		t.locals = map[uint64]*funcLocals{}
+	}
+
+	r := t.dwarf.Reader()
+	cu, err := r.SeekPC(entryPC)
+	if err != nil {
+		// Functions without debug information, e.g. assembly ones, have no variables
+		t.locals[entryPC] = nil
+		return nil, nil
+	}
+
+	fn := &funcLocals{}
+	fn.lowPC, _ = cu.Val(dwarf.AttrLowpc).(uint64)
+	fn.addrBase, _ = cu.Val(dwarf.AttrAddrBase).(int64)
+
+	found := false
+	for !found {
+		entry, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil || entry.Tag == 0 {
+			break
+		}
+
+		lowPC, _ := entry.Val(dwarf.AttrLowpc).(uint64)
+		if entry.Tag != dwarf.TagSubprogram || lowPC != entryPC {
+			r.SkipChildren()
+			continue
+		}
+
+		found = true
+		if err := t.readVars(r, fn, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if !found {
+		fn = nil
+	}
+
+	t.locals[entryPC] = fn
+	return fn, nil
+}
+
+// readVars reads children of the current entry up to the end of its list, variables of lexical blocks are limited to
+// ranges of the block.
+func (t *Table) readVars(r *dwarf.Reader, fn *funcLocals, ranges [][2]uint64) error {
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if entry == nil || entry.Tag == 0 {
+			return nil
+		}
+
+		switch entry.Tag {
+		case dwarf.TagVariable, dwarf.TagFormalParameter:
+			location := entry.AttrField(dwarf.AttrLocation)
+			typeOffset, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+			if location == nil || !ok {
+				break
+			}
+			typ, err := t.dwarf.Type(typeOffset)
+			if err != nil {
+				return err
+			}
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			fn.vars = append(fn.vars, localVar{name: name, typ: typ, location: location, ranges: ranges})
+		case dwarf.TagLexDwarfBlock:
+			blockRanges, err := t.dwarf.Ranges(entry)
+			if err != nil {
+				return err
+			}
+			if err := t.readVars(r, fn, blockRanges); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entry.Children {
+			r.SkipChildren()
+		}
+	}
+}
+
+func inRanges(ranges [][2]uint64, pc uint64) bool {
+	for _, rng := range ranges {
+		if pc >= rng[0] && pc < rng[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// locationExpr returns the location expression of a variable valid at pc, it is either given directly or found in a
+// location list.
+func (t *Table) locationExpr(fn *funcLocals, location *dwarf.Field, pc uint64) ([]byte, bool) {
+	switch location.Class {
+	case dwarf.ClassExprLoc, dwarf.ClassBlock:
+		expr, ok := location.Val.([]byte)
+		return expr, ok
+	case dwarf.ClassLocListPtr:
+		offset, _ := location.Val.(int64)
+		return t.locList(fn, offset, pc)
+	case dwarf.ClassLocList:
+		offset, _ := location.Val.(int64)
+		return t.locLists(fn, offset, pc)
+	}
+
+	return nil, false
+}
+
+// locList looks pc up in a DWARF 4 location list of .debug_loc.
+func (t *Table) locList(fn *funcLocals, offset int64, pc uint64) ([]byte, bool) {
+	d := dwarfBuf{data: t.debugLoc, pos: int(offset), order: t.byteOrder}
+	base := fn.lowPC
+
+	for d.ok() {
+		begin, end := d.addr(), d.addr()
+		switch {
+		case begin == 0 && end == 0:
+			return nil, false
+		case begin == ^uint64(0):
+			base = end
+			continue
+		}
+
+		expr := d.bytes(int(d.uint16()))
+		if pc >= base+begin && pc < base+end {
+			return expr, d.ok()
+		}
+	}
+
+	return nil, false
+}
+
+// locLists looks pc up in a DWARF 5 location list of .debug_loclists.
+func (t *Table) locLists(fn *funcLocals, offset int64, pc uint64) ([]byte, bool) {
+	const (
+		lleEndOfList = iota
+		lleBaseAddressx
+		lleStartxEndx
+		lleStartxLength
+		lleOffsetPair
+		lleDefaultLocation
+		lleBaseAddress
+		lleStartEnd
+		lleStartLength
+	)
+
+	d := dwarfBuf{data: t.debugLocLists, pos: int(offset), order: t.byteOrder}
+	base := fn.lowPC
+	var defaultExpr []byte
+
+	for d.ok() {
+		var begin, end uint64
+
+		switch kind := d.byte(); kind {
+		case lleEndOfList:
+			return defaultExpr, defaultExpr != nil
+		case lleBaseAddressx:
+			base = t.indexedAddr(fn, d.uleb())
+			continue
+		case lleBaseAddress:
+			base = d.addr()
+			continue
+		case lleStartxEndx:
+			begin, end = t.indexedAddr(fn, d.uleb()), t.indexedAddr(fn, d.uleb())
+		case lleStartxLength:
+			begin = t.indexedAddr(fn, d.uleb())
+			end = begin + d.uleb()
+		case lleOffsetPair:
+			begin, end = base+d.uleb(), base+d.uleb()
+		case lleDefaultLocation:
+			defaultExpr = d.bytes(int(d.uleb()))
+			continue
+		case lleStartEnd:
+			begin, end = d.addr(), d.addr()
+		case lleStartLength:
+			begin = d.addr()
+			end = begin + d.uleb()
+		default:
+			return nil, false
+		}
+
+		expr := d.bytes(int(d.uleb()))
+		if pc >= begin && pc < end {
+			return expr, d.ok()
+		}
+	}
+
+	return nil, false
+}
+
+// indexedAddr reads an address from .debug_addr by its index in the table of the compilation unit.
+func (t *Table) indexedAddr(fn *funcLocals, index uint64) uint64 {
+	pos := fn.addrBase + int64(index)*8
+	if pos < 0 || pos+8 > int64(len(t.debugAddr)) {
+		return 0
+	}
+
+	return t.byteOrder.Uint64(t.debugAddr[pos:])
+}
+
+type piece struct {
+	offset    int64
+	size      int64
+	varOffset int64
+}
+
+// stackPieces decodes parts of a variable stored relative to the frame base. Go compilers always use the canonical
+// frame address as the frame base, so DW_OP_fbreg offsets are relative to it. Expressions with other operations
+// describe computed values and are skipped.
+func stackPieces(expr []byte, typeSize int64) []piece {
+	const (
+		opReg0         = 0x50
+		opReg31        = 0x6f
+		opRegx         = 0x90
+		opFbreg        = 0x91
+		opPiece        = 0x93
+		opCallFrameCFA = 0x9c
+	)
+
+	d := dwarfBuf{data: expr}
+
+	var pieces []piece
+	var varOffset int64
+	hasPieces := false
+	onStack, offset := false, int64(0)
+
+	for d.ok() && d.pos < len(d.data) {
+		switch op := d.byte(); {
+		case op == opFbreg:
+			onStack, offset = true, d.sleb()
+		case op == opCallFrameCFA:
+			onStack, offset = true, 0
+		case op >= opReg0 && op <= opReg31:
+			onStack = false
+		case op == opRegx:
+			d.uleb()
+			onStack = false
+		case op == opPiece:
+			size := int64(d.uleb())
+			if onStack {
+				pieces = append(pieces, piece{offset: offset, size: size, varOffset: varOffset})
+			}
+			varOffset += size
+			hasPieces = true
+			onStack = false
+		default:
+			return nil
+		}
+	}
+
+	if !d.ok() {
+		return nil
+	}
+	if !hasPieces && onStack && typeSize > 0 {
+		pieces = append(pieces, piece{offset: offset, size: typeSize})
+	}
+
+	return pieces
+}
+
+// fieldPath names the field of typ at offset, e.g. ".cache" or ".items[3].name". Strings, slices and interfaces are
+// not descended into, their headers are shown as whole values.
+func fieldPath(typ dwarf.Type, offset int64) string {
+	var path strings.Builder
+
+	for {
+		switch t := typ.(type) {
+		case *dwarf.TypedefType:
+			typ = t.Type
+			continue
+		case *dwarf.StructType:
+			if t.StructName == "string" || strings.HasPrefix(t.StructName, "[]") ||
+				strings.HasPrefix(t.StructName, "runtime.iface") || strings.HasPrefix(t.StructName, "runtime.eface") {
+				return path.String()
+			}
+
+			var next dwarf.Type
+			for _, field := range t.Field {
+				if offset >= field.ByteOffset && offset < field.ByteOffset+field.Type.Size() {
+					path.WriteString("." + field.Name)
+					offset -= field.ByteOffset
+					next = field.Type
+					break
+				}
+			}
+			if next == nil {
+				return path.String()
+			}
+			typ = next
+			continue
+		case *dwarf.ArrayType:
+			elemSize := t.Type.Size()
+			if elemSize <= 0 {
+				return path.String()
+			}
+			i := offset / elemSize
+			path.WriteString(fmt.Sprintf("[%d]", i))
+			offset -= i * elemSize
+			typ = t.Type
+			continue
+		}
+
+		return path.String()
+	}
+}
+
+// dwarfBuf decodes DWARF encoded values, it stops at the first read past the end of data.
+type dwarfBuf struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	err   bool
+}
+
+func (d *dwarfBuf) ok() bool {
+	return !d.err && d.pos <= len(d.data)
+}
+
+func (d *dwarfBuf) bytes(n int) []byte {
+	if n < 0 || d.pos+n > len(d.data) {
+		d.err = true
+		return nil
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *dwarfBuf) byte() byte {
+	b := d.bytes(1)
+	if b == nil {
+		return 0
+	}
+
+	return b[0]
+}
+
+func (d *dwarfBuf) uint16() uint16 {
+	b := d.bytes(2)
+	if b == nil {
+		return 0
+	}
+
+	return d.order.Uint16(b)
+}
+
+func (d *dwarfBuf) addr() uint64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+
+	return d.order.Uint64(b)
+}
+
+func (d *dwarfBuf) uleb() uint64 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		b := d.byte()
+		if d.err {
+			return 0
+		}
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+func (d *dwarfBuf) sleb() int64 {
+	var v int64
+	var shift uint
+	for {
+		b := d.byte()
+		if d.err {
+			return 0
+		}
+		v |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v
+		}
+	}
+}
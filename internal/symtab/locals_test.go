@@ -0,0 +1,168 @@
+package symtab
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestStackPieces(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     []byte
+		typeSize int64
+		want     []piece
+	}{
+		{name: "fbreg", expr: []byte{0x91, 0x78}, typeSize: 8, want: []piece{{offset: -8, size: 8}}},
+		{name: "fbreg multibyte", expr: []byte{0x91, 0xb8, 0x7e}, typeSize: 16, want: []piece{{offset: -200, size: 16}}},
+		{name: "call frame cfa", expr: []byte{0x9c}, typeSize: 24, want: []piece{{offset: 0, size: 24}}},
+		{name: "fbreg of unknown size", expr: []byte{0x91, 0x78}, typeSize: 0, want: nil},
+		{name: "register", expr: []byte{0x50}, typeSize: 8, want: nil},
+		{name: "regx", expr: []byte{0x90, 0x21}, typeSize: 8, want: nil},
+		{name: "pieces in registers", expr: []byte{0x50, 0x93, 0x08, 0x51, 0x93, 0x08}, typeSize: 16, want: nil},
+		{
+			name:     "pieces on stack",
+			expr:     []byte{0x91, 0x00, 0x93, 0x08, 0x91, 0x08, 0x93, 0x08},
+			typeSize: 16,
+			want:     []piece{{offset: 0, size: 8}, {offset: 8, size: 8, varOffset: 8}},
+		},
+		{
+			name:     "pieces split between register and stack",
+			expr:     []byte{0x53, 0x93, 0x08, 0x91, 0x70, 0x93, 0x08},
+			typeSize: 16,
+			want:     []piece{{offset: -16, size: 8, varOffset: 8}},
+		},
+		{name: "unsupported operation", expr: []byte{0x91, 0x78, 0x23, 0x08}, typeSize: 8, want: nil},
+		{name: "truncated", expr: []byte{0x91}, typeSize: 8, want: nil},
+		{name: "truncated piece", expr: []byte{0x9c, 0x93, 0x88}, typeSize: 8, want: nil},
+		{name: "empty", expr: nil, typeSize: 8, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stackPieces(tt.expr, tt.typeSize); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocList(t *testing.T) {
+	var loc locBuilder
+	// Offsets are relative to the low PC of the function until a base address selection entry
+	loc.addr(0x10).addr(0x20).uint16(1).raw(0x9c)
+	loc.addr(^uint64(0)).addr(0x2000)
+	loc.addr(0x0).addr(0x10).uint16(2).raw(0x91, 0x78)
+	loc.addr(0).addr(0)
+	second := loc.Len()
+	loc.addr(0x0).addr(0x8).uint16(1).raw(0x50)
+	loc.addr(0).addr(0)
+	truncated := loc.Len()
+	loc.addr(0x0).addr(0x8).uint16(4).raw(0x9c)
+
+	table := &Table{byteOrder: binary.LittleEndian, debugLoc: loc.Bytes()}
+	fn := &funcLocals{lowPC: 0x1000}
+
+	tests := []struct {
+		name   string
+		offset int
+		pc     uint64
+		want   []byte
+		ok     bool
+	}{
+		{name: "first entry", pc: 0x1010, want: []byte{0x9c}, ok: true},
+		{name: "end of first entry", pc: 0x101f, want: []byte{0x9c}, ok: true},
+		{name: "past first entry", pc: 0x1020},
+		{name: "before first entry", pc: 0x1000},
+		{name: "after base selection", pc: 0x2008, want: []byte{0x91, 0x78}, ok: true},
+		{name: "past the list", pc: 0x2010},
+		{name: "second list", offset: second, pc: 0x1004, want: []byte{0x50}, ok: true},
+		{name: "truncated expression", offset: truncated, pc: 0x1000},
+		{name: "offset past the section", offset: loc.Len() + 1, pc: 0x1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.locList(fn, int64(tt.offset), tt.pc)
+			if ok != tt.ok || !bytes.Equal(got, tt.want) {
+				t.Errorf("got %x, %v, want %x, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLocLists(t *testing.T) {
+	var addrs locBuilder
+	addrs.addr(0xdead).addr(0x3000).addr(0x4000)
+
+	var loc locBuilder
+	loc.raw(4, 0x10, 0x20, 1, 0x9c)                    // offset pair from the low PC
+	loc.raw(6).addr(0x2000).raw(4, 0x0, 0x10, 1, 0x50) // base address, offset pair
+	loc.raw(8).addr(0x5000).raw(0x10, 1, 0x51)         // start and length
+	loc.raw(0)
+	indexed := loc.Len()
+	loc.raw(1, 0, 4, 0x0, 0x8, 1, 0x52) // base address index, offset pair
+	loc.raw(2, 0, 1, 1, 0x53)           // start and end indexes
+	loc.raw(3, 1, 0x8, 1, 0x54)         // start index and length
+	loc.raw(7).addr(0x6000).addr(0x6008).raw(1, 0x55)
+	loc.raw(0)
+	defaults := loc.Len()
+	loc.raw(5, 2, 0x91, 0x78) // default location
+	loc.raw(4, 0x0, 0x8, 1, 0x9c)
+	loc.raw(0)
+	unknown := loc.Len()
+	loc.raw(9)
+
+	table := &Table{byteOrder: binary.LittleEndian, debugLocLists: loc.Bytes(), debugAddr: addrs.Bytes()}
+	fn := &funcLocals{lowPC: 0x1000, addrBase: 8}
+
+	tests := []struct {
+		name   string
+		offset int
+		pc     uint64
+		want   []byte
+		ok     bool
+	}{
+		{name: "offset pair", pc: 0x1010, want: []byte{0x9c}, ok: true},
+		{name: "offset pair from base address", pc: 0x200f, want: []byte{0x50}, ok: true},
+		{name: "start and length", pc: 0x5000, want: []byte{0x51}, ok: true},
+		{name: "past the list", pc: 0x5010},
+		{name: "offset pair from indexed base", offset: indexed, pc: 0x3004, want: []byte{0x52}, ok: true},
+		{name: "start and end indexes", offset: indexed, pc: 0x3fff, want: []byte{0x53}, ok: true},
+		{name: "start index and length", offset: indexed, pc: 0x4004, want: []byte{0x54}, ok: true},
+		{name: "start and end", offset: indexed, pc: 0x6000, want: []byte{0x55}, ok: true},
+		{name: "matching entry over default", offset: defaults, pc: 0x1000, want: []byte{0x9c}, ok: true},
+		{name: "default location", offset: defaults, pc: 0x1008, want: []byte{0x91, 0x78}, ok: true},
+		{name: "unknown entry kind", offset: unknown, pc: 0x1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.locLists(fn, int64(tt.offset), tt.pc)
+			if ok != tt.ok || !bytes.Equal(got, tt.want) {
+				t.Errorf("got %x, %v, want %x, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// locBuilder writes little-endian location list fixtures.
+type locBuilder struct {
+	bytes.Buffer
+}
+
+func (b *locBuilder) addr(v uint64) *locBuilder {
+	_ = binary.Write(&b.Buffer, binary.LittleEndian, v)
+	return b
+}
+
+func (b *locBuilder) uint16(v uint16) *locBuilder {
+	_ = binary.Write(&b.Buffer, binary.LittleEndian, v)
+	return b
+}
+
+func (b *locBuilder) raw(v ...byte) *locBuilder {
+	_, _ = b.Write(v)
+	return b
+}
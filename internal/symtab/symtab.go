@@ -0,0 +1,283 @@
+// Package symtab resolves addresses found in a heap dump using tables of the executable the dump was taken from.
+// Position independent executables are not supported as the dump doesn't record the load address.
+package symtab
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Table resolves program counters to functions and source lines and provides debug information when the binary has
+// it. A nil *Table is valid and resolves nothing, so callers don't need to check whether the binary was given.
+type Table struct {
+	lines     *gosym.Table
+	dwarf     *dwarf.Data
+	symbols   []Symbol
+	byteOrder binary.ByteOrder
+
+	// Sections DWARF reader doesn't expose, they are needed to decode location lists of local variables
+	debugLoc      []byte
+	debugLocLists []byte
+	debugAddr     []byte
+	locals        map[uint64]*funcLocals
+}
+
+// Symbol is a data symbol of the executable, e.g. a global variable.
+type Symbol struct {
+	Name string
+	Addr uint64
+	Size uint64
+}
+
+// Global is a package level variable described in debug information.
+type Global struct {
+	Name string
+	Addr uint64
+	Type dwarf.Type
+}
+
+type Func struct {
+	Name  string
+	File  string
+	Line  int
+	Entry uint64
+}
+
+// Open reads symbol tables from the ELF or Mach-O executable at fpath.
+func Open(fpath string) (*Table, error) {
+	fp, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = fp.Close() }()
+
+	var pclntab []byte
+	var textAddr uint64
+	table := &Table{}
+
+	if elfFile, err := elf.NewFile(fp); err == nil {
+		pclntab, textAddr, err = readELF(elfFile)
+		if err != nil {
+			return nil, err
+		}
+		// Stripped binaries have no debug information, everything that depends on it is skipped
+		table.dwarf, _ = elfFile.DWARF()
+		table.byteOrder = elfFile.ByteOrder
+		table.symbols = elfSymbols(elfFile)
+		table.debugLoc = elfSectionData(elfFile, ".debug_loc")
+		table.debugLocLists = elfSectionData(elfFile, ".debug_loclists")
+		table.debugAddr = elfSectionData(elfFile, ".debug_addr")
+	} else if machoFile, err := macho.NewFile(fp); err == nil {
+		pclntab, textAddr, err = readMachO(machoFile)
+		if err != nil {
+			return nil, err
+		}
+		table.dwarf, _ = machoFile.DWARF()
+		table.byteOrder = machoFile.ByteOrder
+		table.symbols = machoSymbols(machoFile)
+		table.debugLoc = machoSectionData(machoFile, "__debug_loc")
+		table.debugLocLists = machoSectionData(machoFile, "__debug_loclists")
+		table.debugAddr = machoSectionData(machoFile, "__debug_addr")
+	} else {
+		return nil, fmt.Errorf("%s: unsupported executable format", fpath)
+	}
+
+	sort.Slice(table.symbols, func(i, j int) bool { return table.symbols[i].Addr < table.symbols[j].Addr })
+
+	table.lines, err = gosym.NewTable(nil, gosym.NewLineTable(pclntab, textAddr))
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not parse pclntab: %w", fpath, err)
+	}
+
+	return table, nil
+}
+
+func readELF(f *elf.File) ([]byte, uint64, error) {
+	pclntab := f.Section(".gopclntab")
+	text := f.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil, 0, errors.New("no .gopclntab or .text section, is it a Go binary?")
+	}
+
+	data, err := pclntab.Data()
+	return data, text.Addr, err
+}
+
+func readMachO(f *macho.File) ([]byte, uint64, error) {
+	pclntab := f.Section("__gopclntab")
+	text := f.Section("__text")
+	if pclntab == nil || text == nil {
+		return nil, 0, errors.New("no __gopclntab or __text section, is it a Go binary?")
+	}
+
+	data, err := pclntab.Data()
+	return data, text.Addr, err
+}
+
+func elfSectionData(f *elf.File, name string) []byte {
+	section := f.Section(name)
+	if section == nil {
+		return nil
+	}
+
+	data, _ := section.Data()
+	return data
+}
+
+func machoSectionData(f *macho.File, name string) []byte {
+	section := f.Section(name)
+	if section == nil {
+		return nil
+	}
+
+	data, _ := section.Data()
+	return data
+}
+
+func elfSymbols(f *elf.File) []Symbol {
+	elfSyms, _ := f.Symbols()
+
+	var symbols []Symbol
+	for _, sym := range elfSyms {
+		if elf.ST_TYPE(sym.Info) == elf.STT_OBJECT && sym.Value != 0 {
+			symbols = append(symbols, Symbol{Name: sym.Name, Addr: sym.Value, Size: sym.Size})
+		}
+	}
+
+	return symbols
+}
+
+// machoSymbols returns symbols from data sections, Mach-O doesn't record sizes, so they are derived from the address
+// of the next symbol.
+func machoSymbols(f *macho.File) []Symbol {
+	if f.Symtab == nil {
+		return nil
+	}
+
+	dataSections := map[uint8]bool{}
+	for i, section := range f.Sections {
+		if section.Seg == "__DATA" || section.Seg == "__NOPTRDATA" || section.Seg == "__DATA_CONST" {
+			dataSections[uint8(i+1)] = true
+		}
+	}
+
+	var symbols []Symbol
+	for _, sym := range f.Symtab.Syms {
+		if dataSections[sym.Sect] && sym.Value != 0 {
+			symbols = append(symbols, Symbol{Name: sym.Name, Addr: sym.Value})
+		}
+	}
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Addr < symbols[j].Addr })
+	for i := 0; i+1 < len(symbols); i++ {
+		symbols[i].Size = symbols[i+1].Addr - symbols[i].Addr
+	}
+
+	return symbols
+}
+
+// Symbol returns the data symbol containing addr.
+func (t *Table) Symbol(addr uint64) (Symbol, bool) {
+	if t == nil {
+		return Symbol{}, false
+	}
+
+	i := sort.Search(len(t.symbols), func(i int) bool { return t.symbols[i].Addr > addr }) - 1
+	if i < 0 || addr >= t.symbols[i].Addr+t.symbols[i].Size {
+		return Symbol{}, false
+	}
+
+	return t.symbols[i], true
+}
+
+// Func returns the function containing pc together with the source position of pc.
+func (t *Table) Func(pc uint64) (Func, bool) {
+	if t == nil {
+		return Func{}, false
+	}
+
+	file, line, fn := t.lines.PCToLine(pc)
+	if fn == nil {
+		return Func{}, false
+	}
+
+	return Func{
+		Name:  fn.Name,
+		File:  file,
+		Line:  line,
+		Entry: fn.Entry,
+	}, true
+}
+
+// HasDWARF reports whether the binary has debug information.
+func (t *Table) HasDWARF() bool {
+	return t != nil && t.dwarf != nil
+}
+
+// Globals returns package level variables with a static address.
+func (t *Table) Globals() ([]Global, error) {
+	if !t.HasDWARF() {
+		return nil, nil
+	}
+
+	var globals []Global
+
+	r := t.dwarf.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			continue
+		case dwarf.TagVariable:
+		default:
+			r.SkipChildren()
+			continue
+		}
+
+		addr, ok := t.staticAddr(entry)
+		if !ok {
+			continue
+		}
+
+		typeOffset, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+		if !ok {
+			continue
+		}
+
+		typ, err := t.dwarf.Type(typeOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		name, _ := entry.Val(dwarf.AttrName).(string)
+		globals = append(globals, Global{Name: name, Addr: addr, Type: typ})
+	}
+
+	return globals, nil
+}
+
+// staticAddr decodes location consisting of a single DW_OP_addr operation.
+func (t *Table) staticAddr(entry *dwarf.Entry) (uint64, bool) {
+	const opAddr = 0x03
+
+	loc, ok := entry.Val(dwarf.AttrLocation).([]byte)
+	if !ok || len(loc) != 9 || loc[0] != opAddr {
+		return 0, false
+	}
+
+	return t.byteOrder.Uint64(loc[1:]), true
+}
@@ -0,0 +1,34 @@
+package typeinfo
+
+import (
+	"fmt"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+// NameGlobals names pointer slots of data and BSS segments with symbols of the binary, a slot inside a variable is
+// named with the offset, e.g. "main.cache+0x8". It does nothing if the binary isn't given.
+func NameGlobals(h *heap.Heap, table *symtab.Table) error {
+	if table == nil {
+		return nil
+	}
+
+	return h.Segments().Walk(func(segment heap.Segment) error {
+		for _, offset := range segment.PointerOffsets {
+			addr := uint64(segment.Addr) + offset
+			symbol, ok := table.Symbol(addr)
+			if !ok {
+				continue
+			}
+
+			name := symbol.Name
+			if addr != symbol.Addr {
+				name = fmt.Sprintf("%s+%#x", symbol.Name, addr-symbol.Addr)
+			}
+			h.Segments().SetGlobalName(heap.Address(addr), name)
+		}
+
+		return nil
+	})
+}
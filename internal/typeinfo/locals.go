@@ -0,0 +1,32 @@
+package typeinfo
+
+import (
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+// NameLocals names pointer slots of stack frames with local variables and parameters stored there, it does nothing if
+// the binary has no DWARF.
+func NameLocals(h *heap.Heap, table *symtab.Table) error {
+	if !table.HasDWARF() {
+		return nil
+	}
+
+	return h.StackFrames().Walk(func(frame heap.StackFrame) error {
+		// Frames of suspended goroutines are stopped at return addresses, the call itself is the previous instruction
+		pc := frame.CurrentPC
+		if pc > frame.EntryPC {
+			pc--
+		}
+
+		// Go compilers use the canonical frame address, the end of the frame in the dump, as the frame base
+		for _, offset := range frame.PointerOffsets {
+			name, ok := table.LocalName(frame.EntryPC, pc, int64(offset)-int64(frame.Size))
+			if ok {
+				h.StackFrames().SetLocalName(frame.Addr, offset, name)
+			}
+		}
+
+		return nil
+	})
+}
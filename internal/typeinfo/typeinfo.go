@@ -0,0 +1,236 @@
+// Package typeinfo assigns types to heap objects using debug information of the binary. Types of global variables are
+// known from DWARF, they are propagated through typed pointers, slices and arrays to the objects they point to.
+package typeinfo
+
+import (
+	"debug/dwarf"
+	"strconv"
+	"strings"
+
+	"github.com/alexey-medvedchikov/go-heapview/internal/heap"
+	"github.com/alexey-medvedchikov/go-heapview/internal/symtab"
+)
+
+const wordSize = 8
+
+// maxMallocHeader is the distance between the start of an object and the pointer returned by the allocator.
+const maxMallocHeader = 8
+
+type location struct {
+	addr heap.Address
+	typ  dwarf.Type
+}
+
+type propagator struct {
+	heap     *heap.Heap
+	queue    []location
+	pointers map[dwarf.Type]bool
+}
+
+// Assign types objects reachable from global variables of the binary, it does nothing if the binary has no DWARF.
+func Assign(h *heap.Heap, table *symtab.Table) error {
+	globals, err := table.Globals()
+	if err != nil {
+		return err
+	}
+
+	p := propagator{heap: h, pointers: map[dwarf.Type]bool{}}
+	for _, global := range globals {
+		p.push(heap.Address(global.Addr), global.Type)
+	}
+
+	p.run()
+
+	return nil
+}
+
+func (p *propagator) push(addr heap.Address, typ dwarf.Type) {
+	if p.hasPointers(typ) {
+		p.queue = append(p.queue, location{addr: addr, typ: typ})
+	}
+}
+
+func (p *propagator) run() {
+	for len(p.queue) > 0 {
+		loc := p.queue[len(p.queue)-1]
+		p.queue = p.queue[:len(p.queue)-1]
+
+		switch t := loc.typ.(type) {
+		case *dwarf.TypedefType:
+			p.push(loc.addr, t.Type)
+		case *dwarf.PtrType:
+			p.pointer(loc.addr, t)
+		case *dwarf.StructType:
+			if t.StructName == "string" && len(t.Field) == 2 {
+				p.str(loc.addr)
+				continue
+			}
+			if strings.HasPrefix(t.StructName, "[]") && len(t.Field) == 3 {
+				p.slice(loc.addr, t)
+				continue
+			}
+			for _, field := range t.Field {
+				p.push(loc.addr+heap.Address(field.ByteOffset), field.Type)
+			}
+		case *dwarf.ArrayType:
+			elemSize := t.Type.Size()
+			for i := int64(0); i < t.Count && elemSize > 0; i++ {
+				p.push(loc.addr+heap.Address(i*elemSize), t.Type)
+			}
+		}
+	}
+}
+
+// pointer types the object loc points to, the object is scanned only the first time it gets a type.
+func (p *propagator) pointer(addr heap.Address, t *dwarf.PtrType) {
+	if !isTyped(t) {
+		return
+	}
+
+	ptr, ok := p.heap.Word(addr)
+	if !ok {
+		return
+	}
+
+	object, ok := p.start(ptr)
+	if !ok {
+		return
+	}
+
+	if !p.label(object, heap.TypeDesc{Name: typeName(t.Type), Size: uint64(t.Type.Size())}) {
+		return
+	}
+
+	p.push(ptr, t.Type)
+}
+
+// str records the string header at addr and types the string bytes. An object may hold several strings, so the
+// object is typed only by the string starting at its beginning.
+func (p *propagator) str(addr heap.Address) {
+	ptr, ok := p.heap.Word(addr)
+	if !ok {
+		return
+	}
+
+	length, ok := p.heap.Word(addr + wordSize)
+	if !ok || length == 0 {
+		return
+	}
+
+	header := heap.StringHeader{Addr: ptr, Length: uint64(length)}
+	if _, ok := p.heap.Strings().Bytes(header); !ok {
+		return
+	}
+
+	p.heap.Strings().Add(header)
+
+	if object, _ := p.heap.Objects().Find(ptr); ptr == object.Addr {
+		p.label(object, heap.TypeDesc{Name: "string", Size: uint64(length)})
+	}
+}
+
+// slice types the backing array of the slice header at addr as an array of cap elements.
+func (p *propagator) slice(addr heap.Address, t *dwarf.StructType) {
+	elemPtr, ok := t.Field[0].Type.(*dwarf.PtrType)
+	if !ok || !isTyped(elemPtr) {
+		return
+	}
+
+	ptr, ok := p.heap.Word(addr)
+	if !ok {
+		return
+	}
+
+	capacity, ok := p.heap.Word(addr + 2*wordSize)
+	if !ok || capacity == 0 {
+		return
+	}
+
+	object, ok := p.start(ptr)
+	if !ok {
+		return
+	}
+
+	elemSize := elemPtr.Type.Size()
+	if elemSize <= 0 || uint64(capacity)*uint64(elemSize) > object.Size {
+		return
+	}
+
+	name := "[" + strconv.FormatUint(uint64(capacity), 10) + "]" + typeName(elemPtr.Type)
+	if !p.label(object, heap.TypeDesc{Name: name, Size: uint64(capacity) * uint64(elemSize)}) {
+		return
+	}
+
+	for i := int64(0); i < int64(capacity); i++ {
+		p.push(ptr+heap.Address(i*elemSize), elemPtr.Type)
+	}
+}
+
+// start returns the object ptr points to if ptr points at the start of the object or right after its malloc header,
+// interior pointers don't tell the type of the whole object.
+func (p *propagator) start(ptr heap.Address) (heap.Object, bool) {
+	object, ok := p.heap.Objects().Find(ptr)
+	if !ok || ptr-object.Addr > maxMallocHeader {
+		return heap.Object{}, false
+	}
+
+	return object, true
+}
+
+func (p *propagator) label(object heap.Object, typeDesc heap.TypeDesc) bool {
+	if _, ok := p.heap.Objects().Type(object.Addr); ok {
+		return false
+	}
+
+	p.heap.Objects().SetType(object.Addr, typeDesc)
+	return true
+}
+
+// hasPointers reports whether values of the type contain pointers that can be followed.
+func (p *propagator) hasPointers(typ dwarf.Type) bool {
+	if has, ok := p.pointers[typ]; ok {
+		return has
+	}
+
+	// Recursive types are assumed to have pointers while their fields are being checked
+	p.pointers[typ] = true
+
+	var has bool
+	switch t := typ.(type) {
+	case *dwarf.TypedefType:
+		has = p.hasPointers(t.Type)
+	case *dwarf.PtrType:
+		has = isTyped(t)
+	case *dwarf.StructType:
+		for _, field := range t.Field {
+			has = has || p.hasPointers(field.Type)
+		}
+	case *dwarf.ArrayType:
+		has = t.Count > 0 && p.hasPointers(t.Type)
+	}
+
+	p.pointers[typ] = has
+	return has
+}
+
+// isTyped reports whether the pointer has a known pointee type, unsafe.Pointer doesn't.
+func isTyped(t *dwarf.PtrType) bool {
+	switch t.Type.(type) {
+	case nil, *dwarf.VoidType, *dwarf.UnspecifiedType:
+		return false
+	}
+
+	return true
+}
+
+func typeName(typ dwarf.Type) string {
+	if t, ok := typ.(*dwarf.StructType); ok && t.StructName != "" {
+		return t.StructName
+	}
+
+	if name := typ.Common().Name; name != "" {
+		return name
+	}
+
+	return typ.String()
+}
@@ -0,0 +1,60 @@
+// Package units formats quantities for human-readable reports.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bytes formats n using binary prefixes, e.g. 1536 becomes "1.5 KiB".
+func Bytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Percent formats part as a share of total, it returns "-" if total is zero.
+func Percent(part, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+// ParseBytes parses a size like "512", "64KiB", "1.5 MiB" or "2MB", decimal and binary prefixes are both treated as
+// powers of 1024.
+func ParseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+
+	unit := strings.ToUpper(strings.TrimSpace(s[i:]))
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I")
+	exp := 0
+	if unit != "" {
+		if exp = strings.Index("KMGTPE", unit) + 1; exp == 0 || len(unit) != 1 {
+			return 0, fmt.Errorf("invalid size unit %q", s[i:])
+		}
+	}
+
+	return uint64(n * math.Pow(1024, float64(exp))), nil
+}